@@ -0,0 +1,40 @@
+// A simple console demonstration
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mattwach/rpngo/drivers/posix/fs"
+	"mattwach/rpngo/fileops"
+	"mattwach/rpngo/functions"
+	"mattwach/rpngo/rpn"
+	"os"
+)
+
+func run() error {
+	var r rpn.RPN
+	r.Init(256)
+	functions.RegisterAll(&r)
+	var fo fileops.FileOps
+	fo.InitAndRegister(&r, 65536, &fs.FileOpsDriver{})
+
+	if err := r.ExecSlice(os.Args[1:]); err != nil {
+		return err
+	}
+
+	for _, f := range r.Frames {
+		fmt.Println(f.String(true))
+	}
+
+	return nil
+}
+
+func main() {
+	if err := run(); err != nil {
+		// test has already printed the failures
+		if !errors.Is(err, rpn.ErrTestsFailed) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
@@ -93,7 +93,7 @@ func run() error {
 		w, h = screen.ScreenSize()
 		if err := root.Update(&rpnInst, w, h, true); err != nil {
 			if errors.Is(err, input.ErrExit) {
-				return nil
+				return rpnInst.RunHook(rpn.HookShutdown)
 			}
 			return err
 		}
@@ -129,6 +129,9 @@ func (gi *getInput) Init(lcd *ili9341.Ili9341TxtW) {
 func (gi *getInput) GetChar() (key.Key, error) {
 	for {
 		time.Sleep(20 * time.Millisecond)
+		if rpnInst.TimerDue() {
+			return key.KEY_TIMER, nil
+		}
 		k := gi.serial.GetChar()
 		if k != 0 {
 			return k, nil
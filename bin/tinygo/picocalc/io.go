@@ -39,6 +39,9 @@ func (gi *picoCalcIO) RegisterPrint() {
 func (gi *picoCalcIO) GetChar() (key.Key, error) {
 	for {
 		time.Sleep(20 * time.Millisecond)
+		if gi.rpnInst.TimerDue() {
+			return key.KEY_TIMER, nil
+		}
 		k := gi.serial.GetChar()
 		if k != 0 {
 			return k, nil
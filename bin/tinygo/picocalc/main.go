@@ -143,7 +143,7 @@ func run() error {
 		w, h = picocalc.screen.ScreenSize()
 		if err := root.Update(&rpnInst, w, h, true); err != nil {
 			if errors.Is(err, input.ErrExit) {
-				return nil
+				return rpnInst.RunHook(rpn.HookShutdown)
 			}
 			return err
 		}
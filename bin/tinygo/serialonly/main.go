@@ -36,8 +36,18 @@ func main() {
 				machine.Serial.WriteByte('\n')
 			}
 		} else {
-			print("Error: ", err.Error(), "\n")
+			printError(err)
 		}
+		if err := r.RunHook(rpn.HookUpdate); err != nil {
+			printError(err)
+		}
+	}
+}
+
+func printError(err error) {
+	print("Error: ", err.Error(), "\n")
+	if err := r.RunErrorHook(err); err != nil {
+		print("Error: ", err.Error(), "\n")
 	}
 }
 
@@ -59,6 +69,12 @@ func readLine() string {
 			}
 		}
 
+		if r.TimerDue() {
+			if err := r.RunHook(rpn.HookTimer); err != nil {
+				printError(err)
+			}
+		}
+
 		time.Sleep(time.Millisecond * 10)
 	}
 }
@@ -6,24 +6,35 @@ import (
 	"math"
 	"mattwach/rpngo/elog"
 	"sort"
+	"strconv"
 	"strings"
 )
 
 var (
-	errUnknownConversionType = errors.New("unknown conversion type")
+	errAliasTooDeep           = errors.New("alias nesting is too deep")
+	errClassAlreadyDefined    = errors.New("unit class already defined")
+	errDimensionInUse         = errors.New("name is already a dimension")
+	errIllegalExponent        = errors.New("illegal exponent")
+	errIllegalUnitName        = errors.New("illegal unit name")
+	errIllegalUnitScale       = errors.New("unit scale can not be zero")
+	errIncompatibleDimensions = errors.New("incompatible dimensions")
+	errUnitAlreadyDefined     = errors.New("unit already defined")
+	errUnknownConversionType  = errors.New("unknown conversion type")
 )
 
 //
 // Implementation:
 //
-// Step 1: interpret the type string via a series of aliases
-// Step 2: flip term 1 if needed, or return an error if there is no way to
-//   convert
-// Step 3: convert numerators
-// Step 4: If needed
-//  a) invert
-//  b) convert numerators
-//  c) invert
+// Step 1: split the type string into terms.  Everything after the first
+//   / is a denominator and each term can have an integer exponent. e.g.
+//   kg*m/s^2
+// Step 2: expand aliases and reduce every term to a scale and a set of
+//   base dimensions (length, time, mass, ...)
+// Step 3: check that both sides have the same dimensions, or inverted
+//   dimensions (e.g. s/m and m/s), or are a mass and its weight on planet
+//   earth (e.g. lb>newton).  Otherwise return an error
+// Step 4: scale to base units and back down to the target units.  Offsets
+//   (e.g. temperature) are only applied to single, unscaled units.
 //
 // Done!
 
@@ -47,12 +58,7 @@ var distantConvert = []unit{
 	{1000.0000, 0, []string{"kilometer", "kilometers", "km"}},
 	{0.3048, 0, []string{"foot", "feet", "ft"}},
 	{0.9144, 0, []string{"yard", "yards", "yd"}},
-	{0.155849127913, 0, []string{"_gallonmeters"}},
-	{0.0779245639837, 0, []string{"_pintmeter"}},
-	{0.0170183442005, 0, []string{"_teaspoonmeters"}},
-	{0.0245446996271, 0, []string{"_tablespoonmeters"}},
-	{0.098178798467, 0, []string{"_quartmeter"}},
-	{63.6149072152, 0, []string{"_acremeter"}},
+	{1852.0000, 0, []string{"nauticalmile", "nauticalmiles", "nmi"}},
 }
 
 //
@@ -71,18 +77,17 @@ var timeConvert = []unit{
 }
 
 //
-// Weight/Mass Conversions (planet earth :) )
+// Conversion to grams
 //
 
 var massConvert = []unit{
 	{1.00000, 0, []string{"gram", "grams", "g"}},
 	{1000.00000, 0, []string{"kilogram", "kilograms", "kg"}},
+	{0.00100, 0, []string{"milligram", "milligrams", "mg"}},
 	{28.3495231, 0, []string{"ounce", "ounces", "oz"}},
 	{453.59237, 0, []string{"pound", "pounds", "lbs", "lb"}},
 	{907184.74000, 0, []string{"ton", "tons"}},
-	{101.9680, 0, []string{"newton", "newtons", "n"}},
-	{101968.0000, 0, []string{"kilonewton", "kilonewtons"}},
-	{10196800.0000, 0, []string{"_barnewton"}},
+	{1000000.00000, 0, []string{"tonne", "tonnes"}},
 }
 
 //
@@ -120,29 +125,215 @@ var angleConvert = []unit{
 }
 
 //
-// Energy
+// Temperature
+//
+
+var temperatureConvert = []unit{
+	{1.0, 0, []string{"c", "celsius"}},
+	{5.0 / 9.0, -32, []string{"f", "fahrenheit"}},
+	{1.0, -273.15, []string{"k", "kelvin"}},
+}
+
+//
+// Electrical current (amperes)
+//
+
+var currentConvert = []unit{
+	{1.0000, 0, []string{"ampere", "amperes", "amp", "amps", "A"}},
+	{0.0010, 0, []string{"milliamp", "milliamps", "mA"}},
+	{1.0e-6, 0, []string{"microamp", "microamps", "uA"}},
+	{1000.0000, 0, []string{"kiloamp", "kiloamps", "kA"}},
+}
+
+//
+// Energy (joules)
 //
 
 var energyConvert = []unit{
-	{1.0000, 0, []string{"joules", "j"}},
-	{1000.0000, 0, []string{"kilojoules", "kj"}},
-	{1000000.0000, 0, []string{"megajoules", "mj"}},
-	{3600000.0000, 0, []string{"kwh"}},
+	{1.0000, 0, []string{"joules", "joule", "j", "J"}},
+	{1000.0000, 0, []string{"kilojoules", "kj", "kJ"}},
+	{1000000.0000, 0, []string{"megajoules", "mj", "MJ"}},
+	{3600.0000, 0, []string{"wh", "Wh"}},
+	{3600000.0000, 0, []string{"kwh", "kWh"}},
 	{745.699872, 0, []string{"hps"}},
 	{1055.0600, 0, []string{"btu"}},        // EC standard
 	{105506000.0000, 0, []string{"therm"}}, // EC standard
 	{4.2000, 0, []string{"calorie", "cal", "calories"}},
 	{4184.0000, 0, []string{"kilocalorie", "kilocalories"}},
+	{1.0e-7, 0, []string{"erg", "ergs"}},
+	{1.602176634e-19, 0, []string{"electronvolt", "electronvolts", "ev", "eV"}},
 }
 
 //
-// Temperature
+// Area (square meters)
 //
 
-var temperatureConvert = []unit{
-	{1.0, 0, []string{"c", "celsius"}},
-	{5.0 / 9.0, -32, []string{"f", "fahrenheit"}},
-	{1.0, -273.15, []string{"k", "kelvin"}},
+var areaConvert = []unit{
+	{1.0000, 0, []string{"sqm"}},
+	{1000000.0000, 0, []string{"sqkm"}},
+	{0.09290304, 0, []string{"sqft"}},
+	{0.00064516, 0, []string{"sqin"}},
+	{2589988.110336, 0, []string{"sqmi"}},
+	{4046.8564224, 0, []string{"acre", "acres"}},
+	{10000.0000, 0, []string{"hectare", "hectares", "ha"}},
+}
+
+//
+// Volume (cubic meters)
+//
+
+var volumeConvert = []unit{
+	{1.0000, 0, []string{"cubicmeter", "cubicmeters"}},
+	{0.0010, 0, []string{"liter", "liters", "litre", "litres", "l", "L"}},
+	{1.0e-6, 0, []string{"milliliter", "milliliters", "ml", "mL", "cc"}},
+	{0.003785411784, 0, []string{"gallon", "gallons", "gal"}},
+	{0.00454609, 0, []string{"impgallon", "impgallons"}},
+	{0.000946352946, 0, []string{"quart", "quarts", "qt"}},
+	{0.000473176473, 0, []string{"pint", "pints", "pt"}},
+	{0.0002365882365, 0, []string{"cup", "cups"}},
+	{2.95735295625e-5, 0, []string{"floz", "fluidounce", "fluidounces"}},
+	{1.478676478125e-5, 0, []string{"tablespoon", "tablespoons", "tbsp"}},
+	{4.92892159375e-6, 0, []string{"teaspoon", "teaspoons", "tsp"}},
+}
+
+//
+// Speed (meters/second)
+//
+
+var speedConvert = []unit{
+	{1.0000, 0, []string{"mps"}},
+	{0.44704, 0, []string{"mph"}},
+	{1.0 / 3.6, 0, []string{"kph", "kmh"}},
+	{1852.0 / 3600.0, 0, []string{"knot", "knots"}},
+}
+
+//
+// Force (newtons)
+//
+
+var forceConvert = []unit{
+	{1.0000, 0, []string{"newton", "newtons", "n", "N"}},
+	{1000.0000, 0, []string{"kilonewton", "kilonewtons", "kN"}},
+	{1.0e-5, 0, []string{"dyne", "dynes"}},
+	{4.4482216152605, 0, []string{"poundforce", "lbf"}},
+	{0.27801385095378, 0, []string{"ounceforce", "ozf"}},
+	{9.80665, 0, []string{"kilogramforce", "kgf"}},
+}
+
+//
+// Power (watts)
+//
+
+var powerConvert = []unit{
+	{1.0000, 0, []string{"watt", "watts", "W"}},
+	{0.0010, 0, []string{"milliwatt", "milliwatts", "mW"}},
+	{1000.0000, 0, []string{"kilowatt", "kilowatts", "kw", "kW"}},
+	{1000000.0000, 0, []string{"megawatt", "megawatts", "mw", "MW"}},
+	{745.699872, 0, []string{"horsepower", "hp"}},
+}
+
+//
+// Pressure (pascals)
+//
+
+var pressureConvert = []unit{
+	{1.0000, 0, []string{"pascal", "pascals", "pa", "Pa"}},
+	{1000.0000, 0, []string{"kilopascal", "kilopascals", "kpa", "kPa"}},
+	{1000000.0000, 0, []string{"megapascal", "megapascals", "MPa"}},
+	{100000.0000, 0, []string{"bar", "bars"}},
+	{100.0000, 0, []string{"millibar", "millibars", "mbar"}},
+	{101325.0000, 0, []string{"atmosphere", "atmospheres", "atm"}},
+	{6894.757293168, 0, []string{"psi"}},
+	{133.322387415, 0, []string{"mmhg", "mmHg"}},
+	{3386.389, 0, []string{"inhg", "inHg"}},
+	{101325.0 / 760.0, 0, []string{"torr"}},
+}
+
+//
+// Electrical charge (coulombs)
+//
+
+var chargeConvert = []unit{
+	{1.0000, 0, []string{"coulomb", "coulombs"}},
+	{3600.0000, 0, []string{"amphour", "amphours", "ah", "Ah"}},
+	{3.6000, 0, []string{"milliamphour", "milliamphours", "mah", "mAh"}},
+}
+
+//
+// Voltage (volts)
+//
+
+var voltageConvert = []unit{
+	{1.0000, 0, []string{"volt", "volts", "V"}},
+	{0.0010, 0, []string{"millivolt", "millivolts", "mV"}},
+	{1.0e-6, 0, []string{"microvolt", "microvolts", "uV"}},
+	{1000.0000, 0, []string{"kilovolt", "kilovolts", "kV"}},
+}
+
+//
+// Resistance (ohms)
+//
+
+var resistanceConvert = []unit{
+	{1.0000, 0, []string{"ohm", "ohms"}},
+	{0.0010, 0, []string{"milliohm", "milliohms"}},
+	{1000.0000, 0, []string{"kilohm", "kilohms", "kohm"}},
+	{1000000.0000, 0, []string{"megohm", "megohms"}},
+}
+
+//
+// Capacitance (farads)
+//
+
+var capacitanceConvert = []unit{
+	{1.0000, 0, []string{"farad", "farads"}},
+	{1.0e-3, 0, []string{"millifarad", "millifarads", "mF"}},
+	{1.0e-6, 0, []string{"microfarad", "microfarads", "uF"}},
+	{1.0e-9, 0, []string{"nanofarad", "nanofarads", "nF"}},
+	{1.0e-12, 0, []string{"picofarad", "picofarads", "pF"}},
+}
+
+//
+// Inductance (henries)
+//
+
+var inductanceConvert = []unit{
+	{1.0000, 0, []string{"henry", "henries"}},
+	{1.0e-3, 0, []string{"millihenry", "millihenries", "mH"}},
+	{1.0e-6, 0, []string{"microhenry", "microhenries", "uH"}},
+	{1.0e-9, 0, []string{"nanohenry", "nanohenries", "nH"}},
+}
+
+//
+// Data rate (bits/second).  Note that these use decimal prefixes, unlike
+// memory sizes.
+//
+
+var dataRateConvert = []unit{
+	{1.0000, 0, []string{"bps", "baud"}},
+	{1.0e3, 0, []string{"kbps"}},
+	{1.0e6, 0, []string{"mbps", "Mbps"}},
+	{1.0e9, 0, []string{"gbps", "Gbps"}},
+	{8.0000, 0, []string{"Bps"}},
+	{8.0e3, 0, []string{"kBps"}},
+	{8.0e6, 0, []string{"MBps"}},
+}
+
+//
+// Fuel economy (kilometers/liter) and fuel consumption (liters/kilometer).
+// These are inverses of each other, so mpg>l100km works.  Fuel consumption
+// has the same dimensions as an area, see fuelClasses.
+//
+
+var fuelEconomyConvert = []unit{
+	{1.0000, 0, []string{"kpl", "kmpl"}},
+	{1.609344 / 3.785411784, 0, []string{"mpg"}},
+	{1.609344 / 4.54609, 0, []string{"mpgimp"}},
+}
+
+var fuelConsumptionConvert = []unit{
+	{1.0000, 0, []string{"lpkm"}},
+	{0.0100, 0, []string{"l100km"}},
 }
 
 //
@@ -150,50 +341,12 @@ var temperatureConvert = []unit{
 //
 
 var aliases = map[string]string{
-	"acre":        "_acremeter*_acremeter",
-	"acres":       "acre",
-	"bar":         "_barnewton/meter*meter",
-	"cadence":     "cycles/minute",
-	"gallon":      "_gallonmeters*_gallonmeters*_gallonmeters",
-	"gallons":     "gallon",
-	"ghz":         "gigacycles/second",
-	"hp":          "hps/second",
-	"hz":          "cycles/second",
-	"khz":         "kilocycles/second",
-	"kilowatt":    "kilojoules/second",
-	"kilowatts":   "kilowatt",
-	"kw":          "kilojoules/second",
-	"liter":       "decimeter*decimeter*decimeter",
-	"litre":       "liter",
-	"liters":      "liter",
-	"litres":      "liter",
-	"milliliter":  "cm*cm*cm",
-	"milliliters": "milliliter",
-	"ml":          "milliliter",
-	"megawatt":    "megajoules/second",
-	"megawatts":   "megawatt",
-	"mw":          "megajoules/second",
-	"mhz":         "megacycles/second",
-	"mph":         "miles/hour",
-	"pascal":      "newton/meter*meter",
-	"pascals":     "pascal",
-	"pa":          "pascal",
-	"kilopascal":  "kilonewtons/meter*meter",
-	"kilopascals": "kilopascal",
-	"kpa":         "kilopascal",
-	"pint":        "_pintmeter*_pintmeter*_pintmeter",
-	"pints":       "pint",
-	"psi":         "pounds/inch*inch",
-	"quart":       "_quartmeter*_quartmeter*_quartmeter",
-	"quarts":      "quart",
-	"rpm":         "cycles/minute",
-	"tablespoon":  "_tablespoonmeters*_tablespoonmeters*_tablespoonmeters",
-	"tablespoons": "tablespoon",
-	"tsp":         "_teaspoonmeters*_teaspoonmeters*_teaspoonmeters",
-	"teaspoon":    "tsp",
-	"teaspoons":   "tsp",
-	"watt":        "joules/second",
-	"watts":       "watt",
+	"cadence": "cycles/minute",
+	"ghz":     "gigacycles/second",
+	"hz":      "cycles/second",
+	"khz":     "kilocycles/second",
+	"mhz":     "megacycles/second",
+	"rpm":     "cycles/minute",
 }
 
 type conversionType struct {
@@ -202,32 +355,96 @@ type conversionType struct {
 	offset    float64
 }
 
+// unitClass holds the dimensions of a class of units.  scale converts the
+// base unit of the class to base dimension units.  For example, the base
+// unit of energy is joules which is 1000 g*m^2/s^2.
+type unitClass struct {
+	scale float64
+	dims  dimensions
+}
+
 type Conversion struct {
 	convertDict map[string]conversionType
+	classes     map[string]unitClass
+	// when currency rates were last updated
+	currencyUpdated string
 }
 
-type conversionData struct {
-	numerator       []conversionType
-	denominator     []conversionType
-	inverted        bool
-	numeratorName   string
-	denominatorName string
+// reducedUnit is a unit expression reduced to base dimensions
+type reducedUnit struct {
+	scale  float64
+	offset float64
+	dims   dimensions
+	// simple is true if the expression was a single unit with no exponent.
+	// Offsets are only applied to simple units
+	simple bool
+	// className is the class of a simple unit
+	className string
 }
 
 func Init() *Conversion {
 	elog.Heap("alloc: /convert/convert.go:214: c := &Conversion{convertDict: make(map[string]conversionType)}")
-	c := &Conversion{convertDict: make(map[string]conversionType)} // object allocated on the heap: escapes at line 223
-	c.insertKeys("Distance", distantConvert)
-	c.insertKeys("Time", timeConvert)
-	c.insertKeys("Force/Weight/Mass (Planet Earth)", massConvert)
-	c.insertKeys("Cycles", cycleConvert)
-	c.insertKeys("Memory", memoryConvert)
-	c.insertKeys("Angles", angleConvert)
-	c.insertKeys("Energy", energyConvert)
-	c.insertKeys("Temperature", temperatureConvert)
+	c := &Conversion{ // object allocated on the heap: escapes at line 223
+		convertDict: make(map[string]conversionType),
+		classes:     make(map[string]unitClass),
+	}
+	c.insertBaseClass("Distance", "length", distantConvert)
+	c.insertBaseClass("Time", "time", timeConvert)
+	c.insertBaseClass("Mass", "mass", massConvert)
+	c.insertBaseClass("Cycles", "cycles", cycleConvert)
+	c.insertBaseClass("Memory", "data", memoryConvert)
+	c.insertBaseClass("Angles", "angle", angleConvert)
+	c.insertBaseClass("Temperature", "temperature", temperatureConvert)
+	c.insertBaseClass("Current", "current", currentConvert)
+	c.insertDerivedClass("Energy", "kg*m^2/s^2", energyConvert)
+	c.insertDerivedClass("Area", "m^2", areaConvert)
+	c.insertDerivedClass("Volume", "m^3", volumeConvert)
+	c.insertDerivedClass("Speed", "m/s", speedConvert)
+	c.insertDerivedClass("Force", "kg*m/s^2", forceConvert)
+	c.insertDerivedClass("Power", "kg*m^2/s^3", powerConvert)
+	c.insertDerivedClass("Pressure", "kg/m*s^2", pressureConvert)
+	c.insertDerivedClass("Charge", "A*s", chargeConvert)
+	c.insertDerivedClass("Voltage", "kg*m^2/s^3*A", voltageConvert)
+	c.insertDerivedClass("Resistance", "kg*m^2/s^3*A^2", resistanceConvert)
+	c.insertDerivedClass("Capacitance", "s^4*A^2/kg*m^2", capacitanceConvert)
+	c.insertDerivedClass("Inductance", "kg*m^2/s^2*A^2", inductanceConvert)
+	c.insertDerivedClass("Data Rate", "bits/s", dataRateConvert)
+	c.insertDerivedClass("Fuel Economy", "km/liter", fuelEconomyConvert)
+	c.insertDerivedClass("Fuel Consumption", "liter/km", fuelConsumptionConvert)
 	return c
 }
 
+// fuelClasses are the classes whose named units only convert to each other
+// or to unit expressions (e.g. km/liter), so that sqm>l100km is an error
+// even though the dimensions match.
+var fuelClasses = map[string]bool{
+	"Fuel Economy":     true,
+	"Fuel Consumption": true,
+}
+
+// weightUnits are the units that convert to a force or a pressure using
+// standardGravity.
+var weightUnits = []string{"g", "g/m^2"}
+
+// insertBaseClass adds a class of units that represents a single base
+// dimension.
+func (c *Conversion) insertBaseClass(className string, dim string, data []unit) {
+	c.classes[className] = unitClass{scale: 1, dims: dimensions{{dim, 1}}}
+	c.insertKeys(className, data)
+}
+
+// insertDerivedClass adds a class of units that is defined in terms of
+// other units.  baseExpr is the base unit of the class (scale = 1)
+func (c *Conversion) insertDerivedClass(className string, baseExpr string, data []unit) {
+	ru, err := c.reduce(baseExpr, 0)
+	if err != nil {
+		elog.Print("Error: bad base expression for ", className, ": ", err.Error())
+		return
+	}
+	c.classes[className] = unitClass{scale: ru.scale, dims: ru.dims}
+	c.insertKeys(className, data)
+}
+
 func (c *Conversion) insertKeys(className string, data []unit) {
 	for _, d := range data {
 		for _, k := range d.names {
@@ -239,166 +456,236 @@ func (c *Conversion) insertKeys(className string, data []unit) {
 	}
 }
 
-func (c *Conversion) Convert(value float64, valueType string, targetType string) (float64, error) {
-	// extract type and class information
-
-	var source conversionData
-	err := c.analyzeType(valueType, &source)
-	if err != nil {
-		return 0, err
+// AddClass creates a new unit class at runtime.  names are the names of the
+// base unit of the class (scale 1, offset 0).  Other units can then be added
+// to the class with AddUnit.  The class name is also the name of its
+// dimension, so it can not be the name of an existing dimension.
+func (c *Conversion) AddClass(className string, names []string) error {
+	if len(className) == 0 {
+		return errIllegalUnitName
 	}
-
-	var target conversionData
-	err = c.analyzeType(targetType, &target)
-	if err != nil {
-		return 0, err
+	if _, ok := c.classes[className]; ok {
+		return fmt.Errorf("%v: %w", className, errClassAlreadyDefined)
 	}
-
-	// check for ratio incompatibility
-
-	if source.isRatio() != target.isRatio() {
-		return 0, errors.New("can not convert between a ratio and scalar")
+	if c.isDimension(className) {
+		return fmt.Errorf("%v: %w", className, errDimensionInUse)
 	}
-
-	// check for inversion eligibility
-
-	if target.isRatio() && (source.numeratorName == target.denominatorName) {
-		target.invert()
+	if err := c.addNames(conversionType{className, 1, 0}, names); err != nil {
+		return err
 	}
+	c.classes[className] = unitClass{scale: 1, dims: dimensions{{className, 1}}}
+	return nil
+}
 
-	// check for numerator compatibility
-
-	if source.numeratorName != target.numeratorName {
-		elog.Heap("alloc: /convert/convert.go:269: source.numeratorName,")
-		return 0, fmt.Errorf(
-			"incompatible numerator types: %s, %s",
-			source.numeratorName, // object allocated on the heap: escapes at line 269
-			target.numeratorName) // object allocated on the heap: escapes at line 270
+// isDimension returns true if name is the name of a dimension used by any
+// class, or of the currency dimension, which is added when rates are set.
+func (c *Conversion) isDimension(name string) bool {
+	if name == currencyDim {
+		return true
 	}
-
-	// check for denominator compatibility, if needed
-
-	if source.isRatio() && source.denominatorName != target.denominatorName {
-		elog.Heap("alloc: /convert/convert.go:278: source.denominatorName,")
-		return 0, fmt.Errorf(
-			"incompatible denominator types: %s, %s",
-			source.denominatorName, // object allocated on the heap: escapes at line 278
-			target.denominatorName) // object allocated on the heap: escapes at line 279
+	for _, class := range c.classes {
+		for _, d := range class.dims {
+			if d.dim == name {
+				return true
+			}
+		}
 	}
+	return false
+}
 
-	// scale the value by each numerator
-
-	for _, snum := range source.numerator {
-		value = c.scaleUp(value, snum.scale, snum.offset)
+// AddUnit defines a new unit at runtime in terms of an existing unit where
+// base = (value + offset) * scale.  The new unit belongs to the same class as
+// the base unit.
+func (c *Conversion) AddUnit(baseName string, scale float64, offset float64, names []string) error {
+	if scale == 0 {
+		return errIllegalUnitScale
 	}
-
-	for _, tnum := range target.numerator {
-		value = c.scaleDown(value, tnum.scale, tnum.offset)
+	base, ok := c.convertDict[baseName]
+	if !ok {
+		return fmt.Errorf("%v: %w", baseName, errUnknownConversionType)
 	}
+	// fold the base unit's own scale and offset into the new unit so that
+	// scaleUp() and scaleDown() can be applied directly.
+	return c.addNames(conversionType{
+		className: base.className,
+		scale:     scale * base.scale,
+		offset:    offset + base.offset/scale,
+	}, names)
+}
 
-	// If needed, scale by each denominator
-
-	if source.isRatio() {
-		for _, sden := range source.denominator {
-			value = c.scaleDown(value, sden.scale, sden.offset)
+func (c *Conversion) addNames(ct conversionType, names []string) error {
+	if len(names) == 0 {
+		return errIllegalUnitName
+	}
+	for _, name := range names {
+		if !isLegalUnitName(name) {
+			return fmt.Errorf("%v: %w", name, errIllegalUnitName)
 		}
-		for _, tden := range target.denominator {
-			value = c.scaleUp(value, tden.scale, tden.offset)
+		if _, ok := c.convertDict[name]; ok {
+			return fmt.Errorf("%v: %w", name, errUnitAlreadyDefined)
 		}
-		if target.inverted {
-			value = 1.0 / value
+		if _, ok := aliases[name]; ok {
+			return fmt.Errorf("%v: %w", name, errUnitAlreadyDefined)
 		}
 	}
+	for _, name := range names {
+		c.convertDict[name] = ct
+	}
+	return nil
+}
 
-	return value, nil
+func isLegalUnitName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	return !strings.ContainsAny(name, "*/>^ \t\n")
 }
 
-func (c *Conversion) analyzeType(t string, data *conversionData) error {
-	numeratorTypeList, denominatorTypeList := c.analyzeTypeStr(t)
-	numeratorTypeList, denominatorTypeList = c.checkForAliases(numeratorTypeList, denominatorTypeList)
-	denominatorTypeList, numeratorTypeList = c.checkForAliases(denominatorTypeList, numeratorTypeList)
+func (c *Conversion) Convert(value float64, valueType string, targetType string) (float64, error) {
+	source, err := c.reduce(valueType, 0)
+	if err != nil {
+		return 0, err
+	}
+	target, err := c.reduce(targetType, 0)
+	if err != nil {
+		return 0, err
+	}
 
-	var numerator []conversionType
-	var denominator []conversionType
+	if source.simple && target.simple && (fuelClasses[source.className] != fuelClasses[target.className]) {
+		return 0, c.incompatible(source, target)
+	}
 
-	for _, numeratorType := range numeratorTypeList {
-		n, ok := c.convertDict[numeratorType]
-		if !ok {
-			return fmt.Errorf("%v: %w", numeratorType, errUnknownConversionType)
+	if source.dims.equal(target.dims) {
+		if source.simple && target.simple {
+			value = c.scaleUp(value, source.scale, source.offset)
+			return c.scaleDown(value, target.scale, target.offset), nil
 		}
-		numerator = append(numerator, n)
+		return value * source.scale / target.scale, nil
 	}
 
-	for _, denominatorType := range denominatorTypeList {
-		d, ok := c.convertDict[denominatorType]
-		if !ok {
-			return fmt.Errorf("%v: %w", denominatorType, errUnknownConversionType)
-		}
-		denominator = append(denominator, d)
+	// e.g. s/m > m/s
+	if source.dims.isInverseOf(target.dims) {
+		return 1.0 / (value * source.scale * target.scale), nil
 	}
 
-	data.init(numerator, denominator)
-	return nil
+	if v, ok := c.convertWeight(value, source, target); ok {
+		return v, nil
+	}
+
+	return 0, c.incompatible(source, target)
 }
 
-func (c *Conversion) analyzeTypeStr(t string) ([]string, []string) {
-	var numeratorType []string
-	var denominatorType []string
-	parts := strings.SplitN(t, "/", 2)
-	numeratorType = strings.Split(parts[0], "*")
-	sort.Strings(numeratorType)
-	if len(parts) > 1 {
-		denominatorType = strings.Split(parts[1], "*")
-		sort.Strings(denominatorType)
-	}
-	return numeratorType, denominatorType
-}
-
-func (c *Conversion) checkForAliases(numerator []string, denominator []string) ([]string, []string) {
-	index := 0
-	for index < len(numerator) {
-		alias := aliases[numerator[index]]
-		if alias != "" {
-			numerator = append(numerator[:index], numerator[index+1:]...)
-			parts := strings.SplitN(alias, "/", 2)
-			numerator = append(numerator, strings.Split(parts[0], "*")...)
-			if len(parts) > 1 {
-				denominator = append(denominator, strings.Split(parts[1], "*")...)
-			}
-			index = 0
-		} else {
-			index++
+func (c *Conversion) incompatible(source, target reducedUnit) error {
+	elog.Heap("alloc: /convert/convert.go:269: source.dims.String(),")
+	return fmt.Errorf(
+		"%w: %s, %s",
+		errIncompatibleDimensions,
+		source.dims.String(), // object allocated on the heap: escapes at line 269
+		target.dims.String()) // object allocated on the heap: escapes at line 270
+}
+
+// convertWeight converts between a mass and a force, or a mass per area and
+// a pressure, using standard gravity.  Mass and force used to be the same
+// class, so lb>newton and psi as pounds/inch^2 still work.
+func (c *Conversion) convertWeight(value float64, source, target reducedUnit) (float64, bool) {
+	accel, err := c.reduce("m/s^2", 0)
+	if err != nil {
+		return 0, false
+	}
+	weight := accel.scale * standardGravity
+	for _, expr := range weightUnits {
+		mass, err := c.reduce(expr, 0)
+		if err != nil {
+			continue
+		}
+		force := mass.dims.mul(accel.dims, 1)
+		if source.dims.equal(mass.dims) && target.dims.equal(force) {
+			return value * source.scale * weight / target.scale, true
+		}
+		if source.dims.equal(force) && target.dims.equal(mass.dims) {
+			return value * source.scale / (weight * target.scale), true
 		}
 	}
-	sort.Strings(numerator)
-	sort.Strings(denominator)
-	return numerator, denominator
+	return 0, false
 }
 
-func (c *conversionData) init(numerator, denominator []conversionType) {
-	c.numerator = numerator
-	c.denominator = denominator
-	c.numeratorName = c.buildClassName(numerator, denominator)
-	c.denominatorName = c.buildClassName(denominator, numerator)
-}
+// standardGravity is the acceleration, in m/s^2, used to convert between
+// mass and weight
+const standardGravity = 9.80665
 
-func (cd *conversionData) buildClassName(numerator, denominator []conversionType) string {
-	nameSet := make(map[string]bool)
-	for _, n := range numerator {
-		nameSet[n.className] = true
+// aliases can refer to other aliases.  This limit prevents an infinite loop
+// if an alias (directly or indirectly) refers to itself.
+const maxAliasDepth = 16
+
+// reduce turns a unit expression such as kg*m/s^2 into a scale and base
+// dimensions.
+func (c *Conversion) reduce(t string, depth int) (reducedUnit, error) {
+	if depth > maxAliasDepth {
+		return reducedUnit{}, fmt.Errorf("%v: %w", t, errAliasTooDeep)
 	}
-	for _, d := range denominator {
-		if nameSet[d.className] {
-			nameSet[d.className] = false
+	ru := reducedUnit{scale: 1}
+	terms := 0
+	for i, part := range strings.Split(t, "/") {
+		sign := 1
+		if i > 0 {
+			// everything after the first / is in the denominator
+			sign = -1
+		}
+		for _, term := range strings.Split(part, "*") {
+			name, exp, err := parseTerm(term)
+			if err != nil {
+				return reducedUnit{}, err
+			}
+			exp *= sign
+			terms++
+			// aliases have priority over units with the same name
+			if alias, ok := aliases[name]; ok {
+				aru, err := c.reduce(alias, depth+1)
+				if err != nil {
+					return reducedUnit{}, err
+				}
+				ru.scale *= math.Pow(aru.scale, float64(exp))
+				ru.dims = ru.dims.mul(aru.dims, exp)
+				continue
+			}
+			ct, ok := c.convertDict[name]
+			if !ok {
+				return reducedUnit{}, fmt.Errorf("%v: %w", name, errUnknownConversionType)
+			}
+			class := c.classes[ct.className]
+			ru.scale *= math.Pow(ct.scale*class.scale, float64(exp))
+			ru.dims = ru.dims.mul(class.dims, exp)
+			if exp == 1 {
+				ru.offset = ct.offset
+				ru.simple = true
+				ru.className = ct.className
+			}
 		}
 	}
-	var nameList []string
-	for _, n := range numerator {
-		if nameSet[n.className] {
-			nameList = append(nameList, n.className)
+	if terms > 1 {
+		ru.simple = false
+		ru.offset = 0
+		ru.className = ""
+	}
+	return ru, nil
+}
+
+// parseTerm splits a term like m^2 into a name and exponent.
+func parseTerm(term string) (string, int, error) {
+	name := term
+	exp := 1
+	if idx := strings.IndexByte(term, '^'); idx >= 0 {
+		name = term[:idx]
+		v, err := strconv.Atoi(term[idx+1:])
+		if err != nil || v == 0 {
+			return "", 0, fmt.Errorf("%v: %w", term, errIllegalExponent)
 		}
+		exp = v
 	}
-	return strings.Join(nameList, "*")
+	if len(name) == 0 {
+		return "", 0, fmt.Errorf("%v: %w", term, errUnknownConversionType)
+	}
+	return name, exp, nil
 }
 
 func (c *Conversion) scaleUp(value float64, scale float64, offset float64) float64 {
@@ -422,7 +709,10 @@ func (c *Conversion) Help() string {
 	}
 	sort.Strings(classNames)
 
-	var lines []string
+	lines := []string{
+		"Units can be combined with * and /, and raised to integer powers\n" +
+			"with ^.  Everything after the first / is a denominator.\n" +
+			"Examples: 5 km>mi  2 m^3>liter  3 kg*m^2/s^2>j  2 watt/m^2>watt/ft^2\n"}
 	for _, className := range classNames {
 		lines = append(lines, "\n"+className+":")
 		sort.Strings(classes[className])
@@ -467,13 +757,3 @@ func dumpColumns(lines []string, nameList []string) []string {
 	lines = append(lines, "\n")
 	return lines
 }
-
-func (cd *conversionData) isRatio() bool {
-	return len(cd.denominator) > 0
-}
-
-func (cd *conversionData) invert() {
-	cd.numerator, cd.denominator = cd.denominator, cd.numerator
-	cd.numeratorName, cd.denominatorName = cd.denominatorName, cd.numeratorName
-	cd.inverted = !cd.inverted
-}
@@ -3,6 +3,7 @@ package convert
 import (
 	"errors"
 	"fmt"
+	"math"
 	"testing"
 )
 
@@ -296,7 +297,7 @@ func TestConvert(t *testing.T) {
 			valueType: "acre",
 			targetType: "ft*ft",
 			valMult: 1,
-			wantVal: 217799, // rounding error
+			wantVal: 217800,
 		},
 		{
 			value: 5,
@@ -380,14 +381,14 @@ func TestConvert(t *testing.T) {
 			valueType: "gallons",
 			targetType: "pints",
 			valMult: 1,
-			wantVal: 39, // rounding error
+			wantVal: 40,
 		},
 		{
 			value: 5,
 			valueType: "gallons",
 			targetType: "quarts",
 			valMult: 1,
-			wantVal: 19, // rounding error
+			wantVal: 20,
 		},
 		{
 			value: 5,
@@ -401,7 +402,7 @@ func TestConvert(t *testing.T) {
 			valueType: "pint",
 			targetType: "tablespoon",
 			valMult: 10,
-			wantVal: 1599, // rounding error
+			wantVal: 1600,
 		},
 		{
 			value: 5,
@@ -435,3 +436,504 @@ func TestConvert(t *testing.T) {
 		})
 	}
 }
+
+func TestAddUnit(t *testing.T) {
+	data := []struct {
+		name       string
+		setup      func(c *Conversion) error
+		value      float64
+		valueType  string
+		targetType string
+		valMult    float64
+		wantVal    int
+		wantErr    error
+	}{
+		{
+			name: "mils",
+			setup: func(c *Conversion) error {
+				return c.AddUnit("in", 0.001, 0, []string{"mil", "mils"})
+			},
+			value:      5,
+			valueType:  "mm",
+			targetType: "mils",
+			valMult:    1,
+			wantVal:    196,
+		},
+		{
+			name: "rankine",
+			setup: func(c *Conversion) error {
+				return c.AddUnit("f", 1, -459.67, []string{"rankine", "ra"})
+			},
+			value:      500,
+			valueType:  "ra",
+			targetType: "c",
+			valMult:    100,
+			wantVal:    462,
+		},
+		{
+			name: "new class",
+			setup: func(c *Conversion) error {
+				if err := c.AddClass("ADC", []string{"count", "counts"}); err != nil {
+					return err
+				}
+				return c.AddUnit("counts", 4096, 0, []string{"fullscale"})
+			},
+			value:      0.5,
+			valueType:  "fullscale",
+			targetType: "counts",
+			valMult:    1,
+			wantVal:    2048,
+		},
+		{
+			name: "new class ratio",
+			setup: func(c *Conversion) error {
+				return c.AddClass("ADC", []string{"count", "counts"})
+			},
+			value:      100,
+			valueType:  "counts/ms",
+			targetType: "counts/s",
+			valMult:    1,
+			wantVal:    100000,
+		},
+		{
+			name: "unknown base",
+			setup: func(c *Conversion) error {
+				return c.AddUnit("foo", 1, 0, []string{"bar"})
+			},
+			wantErr: errUnknownConversionType,
+		},
+		{
+			name: "duplicate unit",
+			setup: func(c *Conversion) error {
+				return c.AddUnit("in", 1, 0, []string{"mm"})
+			},
+			wantErr: errUnitAlreadyDefined,
+		},
+		{
+			name: "duplicate alias",
+			setup: func(c *Conversion) error {
+				return c.AddUnit("in", 1, 0, []string{"mph"})
+			},
+			wantErr: errUnitAlreadyDefined,
+		},
+		{
+			name: "duplicate class",
+			setup: func(c *Conversion) error {
+				return c.AddClass("Time", []string{"tick"})
+			},
+			wantErr: errClassAlreadyDefined,
+		},
+		{
+			name: "class named after a dimension",
+			setup: func(c *Conversion) error {
+				return c.AddClass("length", []string{"span"})
+			},
+			wantErr: errDimensionInUse,
+		},
+		{
+			name: "class named after the currency dimension",
+			setup: func(c *Conversion) error {
+				return c.AddClass("currency", []string{"token"})
+			},
+			wantErr: errDimensionInUse,
+		},
+		{
+			name: "illegal name",
+			setup: func(c *Conversion) error {
+				return c.AddUnit("in", 1, 0, []string{"a/b"})
+			},
+			wantErr: errIllegalUnitName,
+		},
+		{
+			name: "no names",
+			setup: func(c *Conversion) error {
+				return c.AddClass("ADC", nil)
+			},
+			wantErr: errIllegalUnitName,
+		},
+		{
+			name: "zero scale",
+			setup: func(c *Conversion) error {
+				return c.AddUnit("in", 0, 0, []string{"zero"})
+			},
+			wantErr: errIllegalUnitScale,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			c := Init()
+			err := d.setup(c)
+			if !errors.Is(err, d.wantErr) {
+				t.Fatalf("setup err=%v, want %v", err, d.wantErr)
+			}
+			if d.wantErr != nil {
+				return
+			}
+			val, err := c.Convert(d.value, d.valueType, d.targetType)
+			if err != nil {
+				t.Fatalf("err=%v, want nil", err)
+			}
+			gotVal := int(val * d.valMult)
+			if d.wantVal != gotVal {
+				t.Errorf("val=%v, want %v", gotVal, d.wantVal)
+			}
+		})
+	}
+}
+
+func TestConvertExpressions(t *testing.T) {
+	data := []struct {
+		value      float64
+		valueType  string
+		targetType string
+		valMult    float64
+		wantVal    int
+		wantErr    error
+	}{
+		{
+			value:      2,
+			valueType:  "m^3",
+			targetType: "liter",
+			valMult:    1,
+			wantVal:    2000,
+		},
+		{
+			value:      3,
+			valueType:  "kg*m^2/s^2",
+			targetType: "j",
+			valMult:    1,
+			wantVal:    3,
+		},
+		{
+			value:      3,
+			valueType:  "kg*m*m/s/s",
+			targetType: "kj",
+			valMult:    10000,
+			wantVal:    30,
+		},
+		{
+			value:      1,
+			valueType:  "kwh",
+			targetType: "watt*hour",
+			valMult:    1,
+			wantVal:    1000,
+		},
+		{
+			value:      1000,
+			valueType:  "watt/m^2",
+			targetType: "watt/ft^2",
+			valMult:    10,
+			wantVal:    929,
+		},
+		{
+			value:      1,
+			valueType:  "m*s^-1",
+			targetType: "km/h",
+			valMult:    10,
+			wantVal:    36,
+		},
+		{
+			value:      2,
+			valueType:  "s/m",
+			targetType: "m/s",
+			valMult:    10,
+			wantVal:    5,
+		},
+		{
+			value:      1,
+			valueType:  "in^2",
+			targetType: "cm^2",
+			valMult:    100,
+			wantVal:    645,
+		},
+		{
+			value:      10,
+			valueType:  "c",
+			targetType: "f",
+			valMult:    1,
+			wantVal:    50,
+		},
+		{
+			// temperature differences do not apply offsets
+			value:      10,
+			valueType:  "c/s",
+			targetType: "f/s",
+			valMult:    1,
+			wantVal:    18,
+		},
+		{
+			value:      1,
+			valueType:  "m^2",
+			targetType: "s",
+			wantErr:    errIncompatibleDimensions,
+		},
+		{
+			value:      1,
+			valueType:  "j",
+			targetType: "kg*m/s^2",
+			wantErr:    errIncompatibleDimensions,
+		},
+		{
+			value:      1,
+			valueType:  "m^x",
+			targetType: "m",
+			wantErr:    errIllegalExponent,
+		},
+		{
+			value:      1,
+			valueType:  "m^0",
+			targetType: "m",
+			wantErr:    errIllegalExponent,
+		},
+		{
+			value:      1,
+			valueType:  "m*",
+			targetType: "m",
+			wantErr:    errUnknownConversionType,
+		},
+	}
+
+	for _, d := range data {
+		name := fmt.Sprintf("%v %v>%v", d.value, d.valueType, d.targetType)
+		t.Run(name, func(t *testing.T) {
+			c := Init()
+			val, err := c.Convert(d.value, d.valueType, d.targetType)
+			if !errors.Is(err, d.wantErr) {
+				t.Fatalf("err=%v, want %v", err, d.wantErr)
+			}
+			if d.wantErr != nil {
+				return
+			}
+			gotVal := int(math.Round(val * d.valMult))
+			if d.wantVal != gotVal {
+				t.Errorf("val=%v, want %v", gotVal, d.wantVal)
+			}
+		})
+	}
+}
+
+func TestIncompatibleDimensionsMessage(t *testing.T) {
+	c := Init()
+	_, err := c.Convert(1, "m^2/s", "kg")
+	if err == nil {
+		t.Fatalf("err=nil, want error")
+	}
+	want := "incompatible dimensions: length^2/time, mass"
+	if err.Error() != want {
+		t.Errorf("err=%v, want %v", err.Error(), want)
+	}
+}
+
+func TestConvertCategories(t *testing.T) {
+	data := []struct {
+		value      float64
+		valueType  string
+		targetType string
+		valMult    float64
+		wantVal    int
+	}{
+		// pressure
+		{1, "atm", "Pa", 1, 101325},
+		{1, "atm", "psi", 1000, 14696},
+		{1, "atm", "mmHg", 10, 7600},
+		{1, "atm", "torr", 10, 7600},
+		{1, "bar", "kPa", 1, 100},
+		{1, "MPa", "bars", 1, 10},
+		{30, "inHg", "mbar", 1, 1016},
+		{1, "psi", "lbf/in^2", 1000, 1000},
+		{1, "psi", "pounds/inch*inch", 1000, 1000},
+		{1, "kg/m^2", "Pa", 100000, 980665},
+		{1, "Pa", "N/m^2", 1, 1},
+		// volume
+		{1, "gal", "L", 1000, 3785},
+		{1, "cup", "floz", 1, 8},
+		{1, "tbsp", "tsp", 1, 3},
+		{1, "impgallon", "liters", 1000, 4546},
+		{1, "cc", "ml", 1, 1},
+		{1, "cubicmeter", "ft^3", 100, 3531},
+		// area
+		{1, "hectare", "acres", 1000, 2471},
+		{1, "sqmi", "acres", 1, 640},
+		{1, "sqft", "sqin", 1, 144},
+		{1, "sqkm", "ha", 1, 100},
+		{1, "sqm", "m^2", 1, 1},
+		// speed
+		{60, "mph", "kph", 100, 9656},
+		{10, "knots", "m/s", 1000, 5144},
+		{1, "mps", "km/h", 10, 36},
+		// force
+		{1, "kgf", "N", 100000, 980665},
+		{1, "lbf", "ozf", 1, 16},
+		{1, "kN", "dynes", 1, 100000000},
+		{5, "lbf", "lb", 1000, 5000},
+		{1, "newton", "g", 1000, 101972},
+		{1, "N", "kg*m/s^2", 1, 1},
+		// power
+		{1, "hp", "W", 1, 746},
+		{1, "kW", "hp", 1000, 1341},
+		{1, "MW", "kw", 1, 1000},
+		{500, "mW", "W", 10, 5},
+		{1, "W", "j/s", 1, 1},
+		{1, "btu/hour", "W", 1000, 293},
+		// electrical
+		{1500, "mA", "A", 10, 15},
+		{1, "V", "W/A", 1, 1},
+		{1, "ohm", "V/A", 1, 1},
+		{4.7, "kohm", "ohms", 1, 4700},
+		{2, "megohms", "kilohms", 1, 2000},
+		{1, "uF", "nF", 1, 1000},
+		{470, "pF", "nF", 100, 47},
+		{1, "farad", "coulomb/V", 1, 1},
+		{1, "mH", "uH", 1, 1000},
+		{1, "henry", "V*s/A", 1, 1},
+		{2000, "mAh", "coulombs", 1, 7200},
+		{1, "Ah", "mAh", 1, 1000},
+		{1, "mAh*V", "J", 10, 36},
+		// energy
+		{1, "kWh", "MJ", 10, 36},
+		{1, "W*hour", "Wh", 1, 1},
+		{1, "eV", "J", 1e21, 160},
+		{1, "J", "ergs", 1, 10000000},
+		// data rates
+		{1, "Mbps", "kbps", 1, 1000},
+		{1, "MBps", "Mbps", 1, 8},
+		{115200, "baud", "kBps", 10, 144},
+		{1, "gbps", "bytes/s", 1, 125000000},
+		// fuel economy
+		{10, "kpl", "mpg", 100, 2352},
+		{30, "mpg", "l100km", 100, 784},
+		{5, "l100km", "mpg", 100, 4704},
+		{1, "mpgimp", "mpg", 1000, 833},
+		{1, "lpkm", "l100km", 1, 100},
+		{20, "kpl", "l100km", 1, 5},
+		{1, "km/liter", "kpl", 1, 1},
+		{1, "km/liter", "mpg", 1000, 2352},
+		{5, "l100km", "liter/km", 100, 5},
+	}
+
+	for _, d := range data {
+		name := fmt.Sprintf("%v %v>%v", d.value, d.valueType, d.targetType)
+		t.Run(name, func(t *testing.T) {
+			c := Init()
+			val, err := c.Convert(d.value, d.valueType, d.targetType)
+			if err != nil {
+				t.Fatalf("err=%v, want nil", err)
+			}
+			gotVal := int(math.Round(val * d.valMult))
+			if d.wantVal != gotVal {
+				t.Errorf("val=%v, want %v", gotVal, d.wantVal)
+			}
+		})
+	}
+}
+
+func TestNoDuplicateUnitNames(t *testing.T) {
+	tables := [][]unit{
+		distantConvert, timeConvert, massConvert, cycleConvert, memoryConvert,
+		angleConvert, temperatureConvert, currentConvert, energyConvert,
+		areaConvert, volumeConvert, speedConvert, forceConvert, powerConvert,
+		pressureConvert, chargeConvert, voltageConvert, resistanceConvert,
+		capacitanceConvert, inductanceConvert, dataRateConvert,
+		fuelEconomyConvert, fuelConsumptionConvert,
+	}
+	seen := make(map[string]bool)
+	for _, table := range tables {
+		for _, u := range table {
+			for _, name := range u.names {
+				if seen[name] {
+					t.Errorf("duplicate unit name: %v", name)
+				}
+				seen[name] = true
+			}
+		}
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	c := Init()
+	_, err := c.Convert(100, "usd", "eur")
+	if !errors.Is(err, errUnknownConversionType) {
+		t.Errorf("err=%v, want %v", err, errUnknownConversionType)
+	}
+	rates := []CurrencyRate{{"usd", 1}, {"EUR", 0.8}, {"cup", 24}}
+	if err := c.SetCurrencyRates(rates, "2026-10-01"); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	data := []struct {
+		valueType  string
+		targetType string
+		want       float64
+	}{
+		{"usd", "eur", 80},
+		{"USD", "EUR", 80},
+		{"eur", "usd", 125},
+		{"CUP", "usd", 100.0 / 24},
+		{"eur/kg", "usd/g", 0.125},
+	}
+	for _, d := range data {
+		got, err := c.Convert(100, d.valueType, d.targetType)
+		if err != nil {
+			t.Errorf("%v>%v err=%v", d.valueType, d.targetType, err)
+			continue
+		}
+		if math.Abs(got-d.want) > 1e-9 {
+			t.Errorf("%v>%v got %v, want %v", d.valueType, d.targetType, got, d.want)
+		}
+	}
+	// cup is still a volume
+	if _, err := c.Convert(1, "cup", "ml"); err != nil {
+		t.Errorf("cup>ml err=%v", err)
+	}
+	updated, count := c.CurrencyInfo()
+	if (updated != "2026-10-01") || (count != 3) {
+		t.Errorf("CurrencyInfo()=%v, %v want 2026-10-01, 3", updated, count)
+	}
+
+	// a bad table leaves the old one in place
+	err = c.SetCurrencyRates([]CurrencyRate{{"gbp", 1}, {"jpy", 0}}, "2026-10-02")
+	if !errors.Is(err, errIllegalCurrencyRate) {
+		t.Errorf("err=%v, want %v", err, errIllegalCurrencyRate)
+	}
+	if _, err := c.Convert(1, "usd", "eur"); err != nil {
+		t.Errorf("usd>eur err=%v", err)
+	}
+
+	// replacing the table removes old currencies
+	if err := c.SetCurrencyRates([]CurrencyRate{{"gbp", 1}}, "2026-10-03"); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if _, err := c.Convert(1, "usd", "gbp"); !errors.Is(err, errUnknownConversionType) {
+		t.Errorf("err=%v, want %v", err, errUnknownConversionType)
+	}
+
+	if err := c.SetCurrencyRate(CurrencyRate{"chf", 0.9}, "2026-10-04"); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	got, err := c.Convert(9, "chf", "gbp")
+	if (err != nil) || (math.Abs(got-10) > 1e-9) {
+		t.Errorf("chf>gbp got %v, %v want 10", got, err)
+	}
+
+	if err := c.SetCurrencyRate(CurrencyRate{"l", 1}, ""); !errors.Is(err, errUnitAlreadyDefined) {
+		t.Errorf("err=%v, want %v", err, errUnitAlreadyDefined)
+	}
+}
+
+func TestFuelIsNotArea(t *testing.T) {
+	c := Init()
+	for _, d := range [][2]string{{"sqm", "l100km"}, {"l100km", "sqm"}, {"sqm", "kpl"}, {"acre", "mpg"}} {
+		if _, err := c.Convert(1, d[0], d[1]); !errors.Is(err, errIncompatibleDimensions) {
+			t.Errorf("%v>%v err=%v, want %v", d[0], d[1], err, errIncompatibleDimensions)
+		}
+	}
+}
+
+func TestWeightOnlyConvertsMass(t *testing.T) {
+	c := Init()
+	for _, d := range [][2]string{{"s", "mps"}, {"s^2", "m"}, {"m", "s^2"}, {"kg*m", "J"}, {"mps", "s"}} {
+		if _, err := c.Convert(1, d[0], d[1]); !errors.Is(err, errIncompatibleDimensions) {
+			t.Errorf("%v>%v err=%v, want %v", d[0], d[1], err, errIncompatibleDimensions)
+		}
+	}
+}
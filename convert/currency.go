@@ -0,0 +1,109 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errIllegalCurrencyRate = errors.New("currency rate must be positive")
+
+const (
+	currencyClass = "Currency"
+	currencyDim   = "currency"
+)
+
+// CurrencyRate is the value of one unit of the base currency in the
+// given currency.  For example, with a base of usd: {"eur", 0.92}
+type CurrencyRate struct {
+	Code string
+	Rate float64
+}
+
+// SetCurrencyRates replaces the entire currency table.  updated records when
+// the rates were last updated and is reported by CurrencyInfo.  No changes
+// are made if any of the rates are invalid.
+func (c *Conversion) SetCurrencyRates(rates []CurrencyRate, updated string) error {
+	for _, cr := range rates {
+		if _, err := c.currencyNames(cr); err != nil {
+			return err
+		}
+	}
+	c.clearCurrency()
+	for _, cr := range rates {
+		if err := c.setCurrencyRate(cr); err != nil {
+			return err
+		}
+	}
+	c.currencyUpdated = updated
+	return nil
+}
+
+// SetCurrencyRate adds or replaces the rate of a single currency
+func (c *Conversion) SetCurrencyRate(cr CurrencyRate, updated string) error {
+	if err := c.setCurrencyRate(cr); err != nil {
+		return err
+	}
+	c.currencyUpdated = updated
+	return nil
+}
+
+// CurrencyInfo returns when the currency table was last updated and the
+// number of currencies in it.
+func (c *Conversion) CurrencyInfo() (string, int) {
+	// each currency is usually registered as both upper and lower case
+	codes := make(map[string]bool)
+	for name, ct := range c.convertDict {
+		if ct.className == currencyClass {
+			codes[strings.ToLower(name)] = true
+		}
+	}
+	return c.currencyUpdated, len(codes)
+}
+
+func (c *Conversion) setCurrencyRate(cr CurrencyRate) error {
+	names, err := c.currencyNames(cr)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.classes[currencyClass]; !ok {
+		c.classes[currencyClass] = unitClass{scale: 1, dims: dimensions{{currencyDim, 1}}}
+	}
+	for _, name := range names {
+		c.convertDict[name] = conversionType{currencyClass, 1 / cr.Rate, 0}
+	}
+	return nil
+}
+
+// currencyNames returns the lower and upper case versions of a currency
+// code, skipping any that would replace a non-currency unit (e.g. cup).
+func (c *Conversion) currencyNames(cr CurrencyRate) ([]string, error) {
+	if !isLegalUnitName(cr.Code) {
+		return nil, fmt.Errorf("%v: %w", cr.Code, errIllegalUnitName)
+	}
+	if cr.Rate <= 0 {
+		return nil, fmt.Errorf("%v: %w", cr.Code, errIllegalCurrencyRate)
+	}
+	var names []string
+	for _, name := range []string{strings.ToLower(cr.Code), strings.ToUpper(cr.Code)} {
+		if ct, ok := c.convertDict[name]; ok && (ct.className != currencyClass) {
+			continue
+		}
+		if _, ok := aliases[name]; ok {
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%v: %w", cr.Code, errUnitAlreadyDefined)
+	}
+	return names, nil
+}
+
+func (c *Conversion) clearCurrency() {
+	for name, ct := range c.convertDict {
+		if ct.className == currencyClass {
+			delete(c.convertDict, name)
+		}
+	}
+}
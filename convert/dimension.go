@@ -0,0 +1,109 @@
+package convert
+
+import "strconv"
+
+// dimPower is a single base dimension raised to a power. e.g. length^2
+type dimPower struct {
+	dim string
+	exp int
+}
+
+// dimensions is a list of base dimensions, sorted by name.  Dimensions with
+// an exponent of zero are removed so that two equivalent lists can be compared
+// directly.
+type dimensions []dimPower
+
+// mul returns d * o^exp
+func (d dimensions) mul(o dimensions, exp int) dimensions {
+	result := make(dimensions, 0, len(d)+len(o))
+	result = append(result, d...)
+	for _, op := range o {
+		found := false
+		for i := range result {
+			if result[i].dim == op.dim {
+				result[i].exp += op.exp * exp
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, dimPower{op.dim, op.exp * exp})
+		}
+	}
+	return result.normalize()
+}
+
+// normalize removes zero exponents and sorts by dimension name
+func (d dimensions) normalize() dimensions {
+	result := d[:0]
+	for _, dp := range d {
+		if dp.exp != 0 {
+			result = append(result, dp)
+		}
+	}
+	// insertion sort, lists are short
+	for i := 1; i < len(result); i++ {
+		for j := i; (j > 0) && (result[j].dim < result[j-1].dim); j-- {
+			result[j], result[j-1] = result[j-1], result[j]
+		}
+	}
+	return result
+}
+
+func (d dimensions) equal(o dimensions) bool {
+	if len(d) != len(o) {
+		return false
+	}
+	for i := range d {
+		if d[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d dimensions) isInverseOf(o dimensions) bool {
+	if (len(d) == 0) || (len(d) != len(o)) {
+		return false
+	}
+	for i := range d {
+		if (d[i].dim != o[i].dim) || (d[i].exp != -o[i].exp) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the dimensions in a unit-like form. e.g. length^2/time
+func (d dimensions) String() string {
+	if len(d) == 0 {
+		return "dimensionless"
+	}
+	var num string
+	var den string
+	for _, dp := range d {
+		if dp.exp > 0 {
+			num = appendDimPower(num, dp.dim, dp.exp)
+		} else {
+			den = appendDimPower(den, dp.dim, -dp.exp)
+		}
+	}
+	if len(num) == 0 {
+		num = "1"
+	}
+	if len(den) > 0 {
+		return num + "/" + den
+	}
+	return num
+}
+
+func appendDimPower(s string, dim string, exp int) string {
+	if len(s) > 0 {
+		s += "*"
+	}
+	s += dim
+	if exp != 1 {
+		s += "^" + strconv.Itoa(exp)
+	}
+	return s
+}
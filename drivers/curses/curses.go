@@ -20,6 +20,8 @@ type Curses struct {
 	// set to true if ESC was detected.  This is needed to support
 	// KEY_CUT, KEY_COPY, etc
 	escPressed bool
+	// milliseconds that GetChar waits for a key, 0 to wait forever
+	inputTimeout int
 }
 
 func Init() (*Curses, error) {
@@ -150,9 +152,26 @@ func (c *Curses) ResizeWindow(x, y, w, h int) error {
 	if err := c.window.Keypad(true); err != nil {
 		return err
 	}
+	if c.inputTimeout > 0 {
+		c.window.Timeout(c.inputTimeout)
+	}
 	return nil
 }
 
+// SetInputTimeout makes GetChar return 0 if no key is pressed within ms
+// milliseconds.  0 waits forever.
+func (c *Curses) SetInputTimeout(ms int) {
+	c.inputTimeout = ms
+	if c.window == nil {
+		return
+	}
+	if ms > 0 {
+		c.window.Timeout(ms)
+	} else {
+		c.window.Timeout(-1)
+	}
+}
+
 var charMap = map[goncurses.Key]key.Key{
 	goncurses.KEY_LEFT:      key.KEY_LEFT,
 	goncurses.KEY_RIGHT:     key.KEY_RIGHT,
@@ -191,6 +210,10 @@ func (c *Curses) GetChar() (key.Key, error) {
 		return 0, nil
 	}
 	ch := c.window.GetChar()
+	if ch == 0 {
+		// no key before the input timeout
+		return 0, nil
+	}
 	if c.escPressed {
 		return c.getCharWithEscPressed(byte(ch))
 	}
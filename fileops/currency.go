@@ -0,0 +1,74 @@
+package fileops
+
+import (
+	"fmt"
+	"mattwach/rpngo/convert"
+	"mattwach/rpngo/rpn"
+	"strconv"
+	"strings"
+)
+
+const curLoadHelp = "Loads currency conversion rates from a CSV file.\n" +
+	"Each line is code,rate where rate is the number of units of that\n" +
+	"currency per unit of the base currency.  An optional updated,<date>\n" +
+	"line records when the rates were updated.  Lines starting with #\n" +
+	"are ignored. Example file:\n" +
+	"  updated,2026-10-01\n" +
+	"  usd,1\n" +
+	"  eur,0.92\n" +
+	"Example: 'rates.csv' cur.load 100 usd>eur\n" +
+	"See Also: cur.info, cur.rate"
+
+func (fo *FileOps) curLoad(r *rpn.RPN) error {
+	f, err := r.PopFrame()
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		return rpn.ErrExpectedAString
+	}
+	path := f.UnsafeString()
+	sz, err := fo.driver.FileSize(path)
+	if err != nil {
+		return err
+	}
+	if sz > fo.maxFileSize {
+		return fmt.Errorf("file is too large.  %v > %v max bytes", sz, fo.maxFileSize)
+	}
+	data, err := fo.driver.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rates, updated, err := parseCurrencyCSV(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return r.SetCurrencyRates(rates, updated)
+}
+
+func parseCurrencyCSV(data string) ([]convert.CurrencyRate, string, error) {
+	var rates []convert.CurrencyRate
+	var updated string
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if (len(line) == 0) || (line[0] == '#') {
+			continue
+		}
+		parts := strings.SplitN(line, ",", 2)
+		if len(parts) != 2 {
+			return nil, "", fmt.Errorf("line %d: %w", i+1, rpn.ErrSyntax)
+		}
+		name := strings.TrimSpace(parts[0])
+		val := strings.TrimSpace(parts[1])
+		if name == "updated" {
+			updated = val
+			continue
+		}
+		rate, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("line %d: %w", i+1, rpn.ErrExpectedANumber)
+		}
+		rates = append(rates, convert.CurrencyRate{Code: name, Rate: rate})
+	}
+	return rates, updated, nil
+}
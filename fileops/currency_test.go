@@ -0,0 +1,95 @@
+package fileops
+
+import (
+	"errors"
+	"mattwach/rpngo/drivers/posix/fs"
+	"mattwach/rpngo/rpn"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCurLoad(t *testing.T) {
+	data := []struct {
+		name     string
+		filedata string
+		args     []string
+		want     string
+		wantErr  error
+		wantInfo string
+	}{
+		{
+			name:     "basic",
+			filedata: "# rates\nupdated,2026-10-01\nusd,1\neur, 0.8\n\n",
+			args:     []string{"100", "usd>eur"},
+			want:     "80 `eur",
+			wantInfo: "2 currencies, updated 2026-10-01\n",
+		},
+		{
+			name:     "no updated line",
+			filedata: "usd,1\ngbp,0.5\n",
+			args:     []string{"1", "gbp>usd"},
+			want:     "2 `usd",
+			wantInfo: "2 currencies\n",
+		},
+		{
+			name:     "bad line",
+			filedata: "usd,1\neur\n",
+			wantErr:  rpn.ErrSyntax,
+		},
+		{
+			name:     "bad rate",
+			filedata: "usd,1\neur,x\n",
+			wantErr:  rpn.ErrExpectedANumber,
+		},
+		{
+			name:    "missing file",
+			wantErr: os.ErrNotExist,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePath := filepath.Join(dir, "rates.csv")
+			if len(d.filedata) > 0 {
+				if err := os.WriteFile(filePath, []byte(d.filedata), 0644); err != nil {
+					t.Fatalf("error creating temp file: %v", err)
+				}
+			}
+			var r rpn.RPN
+			r.Init(256)
+			var fo FileOps
+			fo.InitAndRegister(&r, 65536, &fs.FileOpsDriver{})
+			args := append([]string{"'" + filePath + "'", "cur.load"}, d.args...)
+			err := r.ExecSlice(args)
+			if !errors.Is(err, d.wantErr) {
+				t.Fatalf("err=%v, want: %v", err, d.wantErr)
+			}
+			if d.wantErr != nil {
+				return
+			}
+			f, err := r.PopFrame()
+			if err != nil {
+				t.Fatalf("err=%v, want nil", err)
+			}
+			if got := f.String(true); got != d.want {
+				t.Errorf("got: %v, want %v", got, d.want)
+			}
+			var info string
+			r.Print = func(msg string) {
+				info += msg
+			}
+			if err := r.Exec("cur.info"); err != nil {
+				t.Fatalf("err=%v", err)
+			}
+			if (len(d.wantInfo) > 0) && (info != d.wantInfo) {
+				t.Errorf("cur.info got: %v, want %v", info, d.wantInfo)
+			}
+			if !strings.HasPrefix(info, "2 currencies") {
+				t.Errorf("cur.info got: %v", info)
+			}
+		})
+	}
+}
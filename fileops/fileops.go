@@ -36,6 +36,9 @@ func (fo *FileOps) InitAndRegister(r *rpn.RPN, maxFileSize int, driver FileOpsDr
 const formatHelp = "Formats an SD card. For safety, must provide a single \"YES\" string argument. Not suported on PCs"
 
 func (fo *FileOps) format(r *rpn.RPN) error {
+	if r.Sandboxed() {
+		return rpn.ErrSandboxed
+	}
 	f, err := r.PopFrame()
 	if err != nil {
 		return err
@@ -81,7 +84,11 @@ func (fo *FileOps) save(r *rpn.RPN) error {
 	if !f.IsString() {
 		return rpn.ErrExpectedAString
 	}
-	path := f.UnsafeString()
+	path, err := r.WritablePath(f.UnsafeString())
+	if err != nil {
+		r.PushFrame(f)
+		return err
+	}
 	data, err := r.PopFrame()
 	if err != nil {
 		return err
@@ -99,7 +106,11 @@ func (fo *FileOps) append(r *rpn.RPN) error {
 	if !f.IsString() {
 		return rpn.ErrExpectedAString
 	}
-	path := f.UnsafeString()
+	path, err := r.WritablePath(f.UnsafeString())
+	if err != nil {
+		r.PushFrame(f)
+		return err
+	}
 	data, err := r.PopFrame()
 	if err != nil {
 		return err
@@ -142,6 +153,9 @@ The exit code of the shell command is set to the variable $rc.
 `
 
 func (fo *FileOps) shell(r *rpn.RPN) error {
+	if r.Sandboxed() {
+		return rpn.ErrSandboxed
+	}
 	f, err := r.PopFrame()
 	if err != nil {
 		return err
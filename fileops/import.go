@@ -0,0 +1,83 @@
+package fileops
+
+import (
+	"fmt"
+	"mattwach/rpngo/rpn"
+	"path/filepath"
+	"strings"
+)
+
+// moduleExt is added to module names to form the file name
+const moduleExt = ".rpn"
+
+// libDir is the library directory under the home directory that is
+// searched by default
+const libDir = "rpnlib"
+
+const importHelp = "Pops a module name and loads name" + moduleExt + " from the " +
+	"first directory in the search path that has it.  A module is only loaded " +
+	"once.  Variables and commands that the module defines are prefixed " +
+	"with the module name, so mean= in stats" + moduleExt + " creates " +
+	"stats.mean.  The search path is the space-separated list of directories " +
+	"in .importpath.  If .importpath is not set, the current directory, the " +
+	"home directory and " + libDir + " in the home directory are searched.  " +
+	"If the name is followed by true, the module is loaded in the sandbox.\n" +
+	"Examples:\n" +
+	"  'geom' import 5 geom.carea\n" +
+	"  'downloaded' true import\n" +
+	"See Also: source, def, sandbox"
+
+func (fo *FileOps) importFn(r *rpn.RPN) error {
+	sandboxed := popSandboxFlag(r)
+	f, err := r.PopFrame()
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		r.PushFrame(f)
+		return rpn.ErrExpectedAString
+	}
+	name := f.UnsafeString()
+	return runScript(r, sandboxed, func() error {
+		return r.Import(name, func() (string, error) {
+			return fo.loadModule(r, name)
+		})
+	})
+}
+
+// loadModule reads the first name.rpn found on the search path
+func (fo *FileOps) loadModule(r *rpn.RPN, name string) (string, error) {
+	dirs, err := importPath(r)
+	if err != nil {
+		return "", err
+	}
+	fname := name + moduleExt
+	for _, dir := range dirs {
+		path := filepath.Join(dir, fname)
+		sz, err := fo.driver.FileSize(path)
+		if err != nil {
+			continue
+		}
+		if sz > fo.maxFileSize {
+			return "", fmt.Errorf("file is too large.  %v > %v max bytes", sz, fo.maxFileSize)
+		}
+		data, err := fo.driver.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("%s: %w", fname, rpn.ErrNotFound)
+}
+
+// importPath returns the directories to search for modules
+func importPath(r *rpn.RPN) ([]string, error) {
+	if s, err := r.GetStringVariable(".importpath"); err == nil {
+		return strings.Fields(s), nil
+	}
+	home, err := HomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return []string{".", home, filepath.Join(home, libDir)}, nil
+}
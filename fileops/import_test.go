@@ -0,0 +1,88 @@
+package fileops
+
+import (
+	"errors"
+	"mattwach/rpngo/drivers/posix/fs"
+	"mattwach/rpngo/functions"
+	"mattwach/rpngo/rpn"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	dir1 := t.TempDir()
+	dir2 := t.TempDir()
+	files := map[string]string{
+		filepath.Join(dir1, "geom.rpn"):  "{(r) $r sq $pi *} 'Area of a circle' 'carea' def 3 pi=",
+		filepath.Join(dir2, "geom.rpn"):  "'shadowed' printlnx",
+		filepath.Join(dir2, "stats.rpn"): "{(a b) $a $b + 2 /} 'Mean of two values' 'mean2' def",
+		filepath.Join(dir2, "bad.rpn"):   "1 2 foo",
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("error creating temp file: %v", err)
+		}
+	}
+	data := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "first dir",
+			args: []string{"'geom'", "import", "2", "geom.carea"},
+			want: "12",
+		},
+		{
+			name: "second dir",
+			args: []string{"'stats'", "import", "3", "5", "stats.mean2"},
+			want: "4",
+		},
+		{
+			name: "loaded once",
+			args: []string{"'geom'", "import", "5", "geom.pi=", "'geom'", "import", "$geom.pi"},
+			want: "5",
+		},
+		{
+			name:    "missing",
+			args:    []string{"'nope'", "import"},
+			wantErr: rpn.ErrNotFound,
+		},
+		{
+			name:    "error",
+			args:    []string{"'bad'", "import"},
+			wantErr: rpn.ErrSyntax,
+		},
+		{
+			name:    "not a string",
+			args:    []string{"5", "import"},
+			wantErr: rpn.ErrExpectedAString,
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			var r rpn.RPN
+			r.Init(256)
+			functions.RegisterAll(&r)
+			var fo FileOps
+			fo.InitAndRegister(&r, 65536, &fs.FileOpsDriver{})
+			args := append([]string{"'" + dir1 + " " + dir2 + "'", ".importpath="}, d.args...)
+			err := r.ExecSlice(args)
+			if !errors.Is(err, d.wantErr) {
+				t.Fatalf("err=%v, want: %v", err, d.wantErr)
+			}
+			if d.wantErr != nil {
+				return
+			}
+			f, err := r.PopFrame()
+			if err != nil {
+				t.Fatalf("err=%v, want nil", err)
+			}
+			if got := f.String(false); got != d.want {
+				t.Errorf("got: %v, want %v", got, d.want)
+			}
+		})
+	}
+}
@@ -6,9 +6,13 @@ func (fo *FileOps) register(r *rpn.RPN) {
 	r.Register(".", fo.source, rpn.CatIO, sourceHelp)
 	r.Register("append", fo.append, rpn.CatIO, appendHelp)
 	r.Register("cd", fo.cd, rpn.CatIO, cdHelp)
+	r.Register("cur.load", fo.curLoad, rpn.CatEng, curLoadHelp)
 	r.Register("format", fo.format, rpn.CatIO, formatHelp)
+	r.Register("import", fo.importFn, rpn.CatIO, importHelp)
 	r.Register("load", fo.load, rpn.CatIO, loadHelp)
+	r.Register("sandbox.dir", fo.sandboxDir, rpn.CatProg, sandboxDirHelp)
 	r.Register("save", fo.save, rpn.CatIO, saveHelp)
 	r.Register("source", fo.source, rpn.CatIO, sourceHelp)
 	r.Register("sh", fo.shell, rpn.CatIO, shellHelp)
+	r.Register("test", fo.test, rpn.CatProg, testHelp)
 }
@@ -0,0 +1,50 @@
+package fileops
+
+import "mattwach/rpngo/rpn"
+
+const sandboxDirHelp = "Pops the directory that sandboxed scripts are allowed " +
+	"to write files to.  Relative paths given to save and append in the " +
+	"sandbox are relative to this directory.  An empty string (the " +
+	"default) means sandboxed scripts can not write files.  Must be an " +
+	"absolute path and can not be changed in the sandbox.\n" +
+	"Example: '/sd/scratch' sandbox.dir\n" +
+	"See Also: sandbox"
+
+func (fo *FileOps) sandboxDir(r *rpn.RPN) error {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		return rpn.ErrExpectedAString
+	}
+	if err := r.SetSandboxDir(f.UnsafeString()); err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	return nil
+}
+
+// popSandboxFlag pops the optional boolean that can follow the name of a
+// script.  If it is true, the script runs in the sandbox.
+func popSandboxFlag(r *rpn.RPN) bool {
+	if len(r.Frames) == 0 {
+		return false
+	}
+	f := r.Frames[len(r.Frames)-1]
+	if !f.IsBool() {
+		return false
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	sandboxed, _ := f.Bool()
+	return sandboxed
+}
+
+// runScript runs fn, which executes a script, in the sandbox if sandboxed
+// is true or everything is sandboxed.  This also limits its runtime.
+func runScript(r *rpn.RPN, sandboxed bool, fn func() error) error {
+	if sandboxed || r.Sandboxed() {
+		return r.RunSandboxed(fn)
+	}
+	return fn()
+}
@@ -0,0 +1,118 @@
+package fileops
+
+import (
+	"errors"
+	"mattwach/rpngo/drivers/posix/fs"
+	"mattwach/rpngo/functions"
+	"mattwach/rpngo/rpn"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSandbox(t *testing.T) {
+	data := []struct {
+		name     string
+		args     []string
+		wantErr  error
+		wantFile string
+	}{
+		{
+			name:    "sh",
+			args:    []string{"{'ls' sh}", "sandbox"},
+			wantErr: rpn.ErrSandboxed,
+		},
+		{
+			name:    "format",
+			args:    []string{"{'.' format}", "sandbox"},
+			wantErr: rpn.ErrSandboxed,
+		},
+		{
+			name:    "save without a dir",
+			args:    []string{"{'hi' 'out.txt' save}", "sandbox"},
+			wantErr: rpn.ErrSandboxed,
+		},
+		{
+			name:     "save in the dir",
+			args:     []string{"DIR", "sandbox.dir", "{'hi' 'out.txt' save}", "sandbox"},
+			wantFile: "out.txt",
+		},
+		{
+			name:     "append in the dir",
+			args:     []string{"DIR", "sandbox.dir", "{'hi' 'sub/../out.txt' append}", "sandbox"},
+			wantFile: "out.txt",
+		},
+		{
+			name:    "save outside the dir",
+			args:    []string{"DIR", "sandbox.dir", "{'hi' '../out.txt' save}", "sandbox"},
+			wantErr: rpn.ErrSandboxed,
+		},
+		{
+			name:    "save absolute outside the dir",
+			args:    []string{"DIR", "sandbox.dir", "{'hi' '/tmp/out.txt' save}", "sandbox"},
+			wantErr: rpn.ErrSandboxed,
+		},
+		{
+			name:    "set dir in sandbox",
+			args:    []string{"{'/' sandbox.dir}", "sandbox"},
+			wantErr: rpn.ErrSandboxed,
+		},
+		{
+			name:    "relative dir",
+			args:    []string{"'scratch'", "sandbox.dir"},
+			wantErr: rpn.ErrIllegalValue,
+		},
+		{
+			name:    "source",
+			args:    []string{"true", "sandbox.global", "SCRIPT", "source"},
+			wantErr: rpn.ErrSandboxed,
+		},
+		{
+			name:    "source in the sandbox",
+			args:    []string{"SCRIPT", "true", "source"},
+			wantErr: rpn.ErrSandboxed,
+		},
+		{
+			name: "source outside of the sandbox",
+			args: []string{"SCRIPT", "false", "source"},
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			dir := t.TempDir()
+			script := filepath.Join(dir, "script.rpn")
+			if err := os.WriteFile(script, []byte("'ls' sh"), 0644); err != nil {
+				t.Fatalf("error creating temp file: %v", err)
+			}
+			var r rpn.RPN
+			r.Init(256)
+			functions.RegisterAll(&r)
+			var fo FileOps
+			fo.InitAndRegister(&r, 65536, &fs.FileOpsDriver{})
+			args := make([]string, len(d.args))
+			for i, arg := range d.args {
+				switch arg {
+				case "DIR":
+					arg = "'" + dir + "'"
+				case "SCRIPT":
+					arg = "'" + script + "'"
+				}
+				args[i] = arg
+			}
+			err := r.ExecSlice(args)
+			if !errors.Is(err, d.wantErr) {
+				t.Fatalf("err=%v, want: %v", err, d.wantErr)
+			}
+			if len(d.wantFile) == 0 {
+				return
+			}
+			got, err := os.ReadFile(filepath.Join(dir, d.wantFile))
+			if err != nil {
+				t.Fatalf("err=%v, want nil", err)
+			}
+			if len(got) == 0 {
+				t.Errorf("file %v is empty", d.wantFile)
+			}
+		})
+	}
+}
@@ -5,10 +5,15 @@ import (
 	"mattwach/rpngo/rpn"
 )
 
-const sourceHelp = "Loads the given path and executes commands within it.\n" +
-	"Example: 'myfile.txt' source"
+const sourceHelp = "Loads the given path and executes commands within it.  " +
+	"If the path is followed by true, the file runs in the sandbox.\n" +
+	"Examples:\n" +
+	"  'myfile.txt' source\n" +
+	"  'downloaded.rpn' true source\n" +
+	"See Also: import, sandbox"
 
 func (fo *FileOps) source(r *rpn.RPN) error {
+	sandboxed := popSandboxFlag(r)
 	f, err := r.PopFrame()
 	if err != nil {
 		return err
@@ -21,8 +26,7 @@ func (fo *FileOps) source(r *rpn.RPN) error {
 	if err != nil {
 		return err
 	}
-	if err := parse.Fields(string(data), r.Exec); err != nil {
-		return err
-	}
-	return nil
+	return runScript(r, sandboxed, func() error {
+		return parse.Fields(string(data), r.Exec)
+	})
 }
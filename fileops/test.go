@@ -0,0 +1,196 @@
+package fileops
+
+import (
+	"errors"
+	"fmt"
+	"mattwach/rpngo/parse"
+	"mattwach/rpngo/rpn"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// testPrefix starts the name of every variable that test runs
+const testPrefix = "test."
+
+const testHelp = "Pops a file pattern, such as 'tests/*.rpn', and sources each " +
+	"matching file.  After a file is sourced, every macro it stored in a " +
+	"variable that starts with " + testPrefix + " is run with an empty stack.  " +
+	"A test passes if it does not give an error.  Prints each result and " +
+	"the number of tests that passed and failed, and gives an error if any " +
+	"failed.  The stack and the " + testPrefix + " variables that were set " +
+	"before are restored when the tests are done.\n" +
+	"Example: 'tests/*.rpn' test\n" +
+	"  where tests/math.rpn has: {2 3 + 5 assert.eq} test.add=\n" +
+	"See Also: assert, assert.eq, assert.err, assert.stack"
+
+func (fo *FileOps) test(r *rpn.RPN) error {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		return rpn.ErrExpectedAString
+	}
+	pattern := f.UnsafeString()
+	paths, err := fo.matchFiles(pattern)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("%s: %w", pattern, rpn.ErrNotFound)
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	saved := make([]rpn.Frame, len(r.Frames))
+	copy(saved, r.Frames)
+	defer func() { r.Frames = append(r.Frames[:0], saved...) }()
+	var passed, failed int
+	for _, path := range paths {
+		r.Println(path)
+		before := testVars(r)
+		p, f, err := fo.runTestFile(r, path, before)
+		restoreTests(r, before)
+		if err != nil {
+			return err
+		}
+		passed += p
+		failed += f
+	}
+	r.Println(fmt.Sprintf("%d passed, %d failed", passed, failed))
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", rpn.ErrTestsFailed, failed, passed+failed)
+	}
+	return nil
+}
+
+// runTestFile sources path and runs the tests that it defined, which are
+// the test variables that changed since before was taken.  It returns
+// the number of tests that passed and failed.
+func (fo *FileOps) runTestFile(r *rpn.RPN, path string, before map[string][]rpn.Frame) (int, int, error) {
+	r.Frames = r.Frames[:0]
+	if err := fo.sourceTests(r, path); err != nil {
+		if errors.Is(err, rpn.ErrInterrupted) {
+			return 0, 0, err
+		}
+		r.Println("  FAIL " + err.Error())
+		return 0, 1, nil
+	}
+	var passed, failed int
+	for _, name := range testNames(r) {
+		if sameFrames(testStack(r, name), before[name]) {
+			// not defined by this file
+			continue
+		}
+		r.Frames = r.Frames[:0]
+		err := runScript(r, false, func() error {
+			return r.Exec("@" + name)
+		})
+		if errors.Is(err, rpn.ErrInterrupted) {
+			return passed, failed, err
+		}
+		if err != nil {
+			r.Println("  FAIL " + name + ": " + err.Error())
+			failed++
+			continue
+		}
+		r.Println("  PASS " + name)
+		passed++
+	}
+	return passed, failed, nil
+}
+
+// matchFiles returns the files that match pattern in sorted order.  Only
+// the file name part of pattern can have wildcards.
+func (fo *FileOps) matchFiles(pattern string) ([]string, error) {
+	dir, base := filepath.Split(pattern)
+	listDir := dir
+	if len(listDir) == 0 {
+		listDir = "."
+	}
+	names, err := fo.driver.ListFiles(listDir, nil)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, name := range names {
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		match, err := filepath.Match(base, name)
+		if err != nil {
+			return nil, rpn.ErrIllegalValue
+		}
+		if match {
+			paths = append(paths, dir+name)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (fo *FileOps) sourceTests(r *rpn.RPN, path string) error {
+	data, err := fo.driver.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return runScript(r, false, func() error {
+		return parse.Fields(string(data), r.Exec)
+	})
+}
+
+// testNames returns the names of the test variables in sorted order
+func testNames(r *rpn.RPN) []string {
+	var names []string
+	for _, name := range r.AppendAllVariableNames(nil) {
+		if strings.HasPrefix(name, testPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// testVars returns a copy of the values of each test variable
+func testVars(r *rpn.RPN) map[string][]rpn.Frame {
+	vars := make(map[string][]rpn.Frame)
+	for _, name := range testNames(r) {
+		vars[name] = append([]rpn.Frame(nil), testStack(r, name)...)
+	}
+	return vars
+}
+
+// restoreTests puts the test variables back to the values in before, so
+// that a test file only leaves the variables that it did not change
+func restoreTests(r *rpn.RPN, before map[string][]rpn.Frame) {
+	for _, name := range testNames(r) {
+		if _, ok := before[name]; !ok {
+			_ = r.ClearVariable(name)
+		}
+	}
+	for name, vals := range before {
+		if sameFrames(testStack(r, name), vals) {
+			continue
+		}
+		_ = r.ClearVariable(name)
+		for _, f := range vals {
+			_ = r.PushVariable(name, f)
+		}
+	}
+}
+
+func testStack(r *rpn.RPN, name string) []rpn.Frame {
+	vals, _ := r.GetVariableStack(name)
+	return vals
+}
+
+func sameFrames(a, b []rpn.Frame) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package fileops
+
+import (
+	"errors"
+	"mattwach/rpngo/drivers/posix/fs"
+	"mattwach/rpngo/functions"
+	"mattwach/rpngo/rpn"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTest(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a_test.rpn": "{2 3 + 5 assert.eq} test.add= {1 2 swap {2 1} assert.stack} test.swap=",
+		"b_test.rpn": "{1 2 - 1 assert.eq} test.sub= {{1 0 /} assert.err} test.err=",
+		"c_test.rpn": "1 2 foo",
+		"helper.rpn": "{false assert} test.never=",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("error creating temp file: %v", err)
+		}
+	}
+	data := []struct {
+		name     string
+		pattern  string
+		wantErr  error
+		wantOut  []string
+		wantNot  []string
+		wantLast string
+	}{
+		{
+			name:     "pass",
+			pattern:  "a_*.rpn",
+			wantOut:  []string{"PASS test.add", "PASS test.swap"},
+			wantLast: "2 passed, 0 failed",
+		},
+		{
+			name:     "fail",
+			pattern:  "[ab]_test.rpn",
+			wantErr:  rpn.ErrTestsFailed,
+			wantOut:  []string{"PASS test.add", "PASS test.err", "FAIL test.sub", "got -1, want 1"},
+			wantNot:  []string{"test.never"},
+			wantLast: "3 passed, 1 failed",
+		},
+		{
+			name:     "source error",
+			pattern:  "c_test.rpn",
+			wantErr:  rpn.ErrTestsFailed,
+			wantOut:  []string{"FAIL 1 2 ->foo<-"},
+			wantLast: "0 passed, 1 failed",
+		},
+		{
+			name:    "no match",
+			pattern: "*.txt",
+			wantErr: rpn.ErrNotFound,
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			var r rpn.RPN
+			r.Init(256)
+			functions.RegisterAll(&r)
+			var fo FileOps
+			fo.InitAndRegister(&r, 65536, &fs.FileOpsDriver{})
+			var out strings.Builder
+			r.Print = func(msg string) { out.WriteString(msg) }
+			err := r.ExecSlice([]string{"7", "'" + filepath.Join(dir, d.pattern) + "'", "test"})
+			if !errors.Is(err, d.wantErr) {
+				t.Fatalf("err=%v, want: %v", err, d.wantErr)
+			}
+			if d.wantErr == nil || errors.Is(d.wantErr, rpn.ErrTestsFailed) {
+				if len(r.Frames) != 1 || r.Frames[0].String(false) != "7" {
+					t.Errorf("stack was not restored: %v", r.Frames)
+				}
+			}
+			got := out.String()
+			for _, want := range d.wantOut {
+				if !strings.Contains(got, want) {
+					t.Errorf("output %q does not contain %q", got, want)
+				}
+			}
+			for _, want := range d.wantNot {
+				if strings.Contains(got, want) {
+					t.Errorf("output %q contains %q", got, want)
+				}
+			}
+			if len(d.wantLast) > 0 && !strings.HasSuffix(got, d.wantLast+"\n") {
+				t.Errorf("output %q does not end with %q", got, d.wantLast)
+			}
+		})
+	}
+}
+
+func TestTestRestoresVariables(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a_test.rpn"), []byte("{2 3 + 5 assert.eq} test.add= {} test.new="), 0644); err != nil {
+		t.Fatalf("error creating temp file: %v", err)
+	}
+	var r rpn.RPN
+	r.Init(256)
+	functions.RegisterAll(&r)
+	var fo FileOps
+	fo.InitAndRegister(&r, 65536, &fs.FileOpsDriver{})
+	var out strings.Builder
+	r.Print = func(msg string) { out.WriteString(msg) }
+	err := r.ExecSlice([]string{
+		"{false assert}", "test.mine=", "{1}", "test.add=", "{2}", "test.add<",
+		"'" + filepath.Join(dir, "a_test.rpn") + "'", "test",
+	})
+	if err != nil {
+		t.Fatalf("err=%v: %s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "PASS test.add") {
+		t.Errorf("output %q does not contain PASS test.add", out.String())
+	}
+	if strings.Contains(out.String(), "test.mine") {
+		t.Errorf("ran a test that the file did not define: %q", out.String())
+	}
+	want := map[string]string{"test.mine": "{false assert}", "test.add": "{1} {2}"}
+	got := make(map[string]string)
+	for _, name := range r.AppendAllVariableNames(nil) {
+		if !strings.HasPrefix(name, testPrefix) {
+			continue
+		}
+		vals, _ := r.GetVariableStack(name)
+		var parts []string
+		for _, f := range vals {
+			parts = append(parts, f.String(true))
+		}
+		got[name] = strings.Join(parts, " ")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("test variables=%v, want %v", got, want)
+	}
+}
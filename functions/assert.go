@@ -0,0 +1,154 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"mattwach/rpngo/parse"
+	"mattwach/rpngo/rpn"
+	"strings"
+)
+
+// assertTolerance is the relative difference allowed between two numbers
+// that assert.eq considers equal
+const assertTolerance = 1e-9
+
+const assertHelp = "Pops a boolean and gives an assertion error if it is false.  " +
+	"An optional message can be given after the boolean.\n" +
+	"Examples:\n" +
+	"  $x 0 > assert\n" +
+	"  $x 0 > 'x must be positive' assert\n" +
+	"See Also: assert.eq, assert.err, assert.stack, test"
+
+func assert(r *rpn.RPN) error {
+	nargs := 1
+	var msg string
+	if len(r.Frames) > 0 {
+		if f := r.Frames[len(r.Frames)-1]; f.IsString() {
+			msg = f.UnsafeString()
+			nargs++
+		}
+	}
+	f, err := r.PeekFrame(nargs - 1)
+	if err != nil {
+		return err
+	}
+	ok, err := f.Bool()
+	if err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-nargs]
+	if ok {
+		return nil
+	}
+	if len(msg) > 0 {
+		return fmt.Errorf("%w: %s", rpn.ErrAssertion, msg)
+	}
+	return rpn.ErrAssertion
+}
+
+const assertEqHelp = "Pops two values and gives an assertion error if they are " +
+	"not equal.  Numbers are equal if they differ by less than one part in " +
+	"a billion, so 0.1 0.2 + 0.3 assert.eq passes.\n" +
+	"Example: 2 3 + 5 assert.eq\n" +
+	"See Also: assert, assert.stack, test"
+
+func assertEq(r *rpn.RPN) error {
+	if len(r.Frames) < 2 {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	got, want, _ := r.Pop2Frames()
+	if !assertEqual(got, want) {
+		return fmt.Errorf("%w: got %s, want %s", rpn.ErrAssertion, got.String(true), want.String(true))
+	}
+	return nil
+}
+
+const assertErrHelp = "Pops a macro and runs it, giving an assertion error if the " +
+	"macro does not give an error.  The stack is restored after the macro " +
+	"runs.\n" +
+	"Example: {1 0 /} assert.err\n" +
+	"See Also: assert, try, test"
+
+func assertErr(r *rpn.RPN) error {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		return rpn.ErrExpectedAString
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	saved := make([]rpn.Frame, len(r.Frames))
+	copy(saved, r.Frames)
+	err = r.ExecMacro(f.UnsafeString())
+	if _, ok := err.(parse.PassThroughError); ok {
+		return err
+	}
+	r.Frames = append(r.Frames[:0], saved...)
+	if err == nil {
+		return fmt.Errorf("%w: %s did not give an error", rpn.ErrAssertion, f.String(true))
+	}
+	return nil
+}
+
+const assertStackHelp = "Pops a macro that pushes the expected values, then gives " +
+	"an assertion error if the stack does not hold exactly those values.  " +
+	"Numbers are compared the same way as assert.eq.\n" +
+	"Example: 1 2 swap {2 1} assert.stack\n" +
+	"See Also: assert.eq, test"
+
+func assertStack(r *rpn.RPN) error {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		return rpn.ErrExpectedAString
+	}
+	got := make([]rpn.Frame, len(r.Frames)-1)
+	copy(got, r.Frames)
+	r.Frames = r.Frames[:0]
+	err = r.ExecMacro(f.UnsafeString())
+	want := r.Frames
+	r.Frames = append(make([]rpn.Frame, 0, len(got)), got...)
+	if err != nil {
+		return err
+	}
+	match := len(got) == len(want)
+	for i := 0; match && (i < len(got)); i++ {
+		match = assertEqual(got[i], want[i])
+	}
+	if !match {
+		return fmt.Errorf("%w: got %s, want %s", rpn.ErrAssertion, framesString(got), framesString(want))
+	}
+	return nil
+}
+
+// assertEqual compares numbers with a relative tolerance, other values
+// must match exactly
+func assertEqual(a, b rpn.Frame) bool {
+	if !a.IsNumber() || !b.IsNumber() {
+		return a.IsEqual(b)
+	}
+	if a.IsInt() && b.IsInt() {
+		return a.UnsafeInt() == b.UnsafeInt()
+	}
+	x := a.UnsafeComplex()
+	y := b.UnsafeComplex()
+	scale := math.Max(1, math.Max(cmplx.Abs(x), cmplx.Abs(y)))
+	return cmplx.Abs(x-y) <= assertTolerance*scale
+}
+
+func framesString(frames []rpn.Frame) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, f := range frames {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(f.String(true))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
@@ -0,0 +1,127 @@
+package functions
+
+import (
+	"mattwach/rpngo/rpn"
+	"testing"
+)
+
+func TestAssert(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"1", "true", "assert"},
+			Want: []string{"1"},
+		},
+		{
+			Args: []string{"true", "'ok'", "assert"},
+		},
+		{
+			Args:    []string{"false", "assert"},
+			WantErr: rpn.ErrAssertion,
+		},
+		{
+			Args:    []string{"false", "'x must be positive'", "assert"},
+			WantErr: rpn.ErrAssertion,
+		},
+		{
+			Args:    []string{"1", "assert"},
+			Want:    []string{"1"},
+			WantErr: rpn.ErrExpectedABoolean,
+		},
+		{
+			Args:    []string{"assert"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestAssertEq(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"2", "3", "+", "5", "assert.eq"},
+		},
+		{
+			Args: []string{"0.1", "0.2", "+", "0.3", "assert.eq"},
+		},
+		{
+			Name: "relative tolerance",
+			Args: []string{"1e20", "3", "*", "3e20", "assert.eq"},
+		},
+		{
+			Args: []string{"'a'", "'a'", "assert.eq"},
+		},
+		{
+			Args:    []string{"1", "2", "assert.eq"},
+			WantErr: rpn.ErrAssertion,
+		},
+		{
+			Args:    []string{"1", "1.001", "assert.eq"},
+			WantErr: rpn.ErrAssertion,
+		},
+		{
+			Args:    []string{"1", "'1'", "assert.eq"},
+			WantErr: rpn.ErrAssertion,
+		},
+		{
+			Args:    []string{"1", "assert.eq"},
+			Want:    []string{"1"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestAssertErr(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"5", "{1 0 /}", "assert.err"},
+			Want: []string{"5"},
+		},
+		{
+			Args: []string{"5", "{d foo}", "assert.err"},
+			Want: []string{"5"},
+		},
+		{
+			Args:    []string{"{1 2 +}", "assert.err"},
+			WantErr: rpn.ErrAssertion,
+		},
+		{
+			Args:    []string{"1", "assert.err"},
+			Want:    []string{"1"},
+			WantErr: rpn.ErrExpectedAString,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestAssertStack(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"1", "2", "swap", "{2 1}", "assert.stack"},
+			Want: []string{"2", "1"},
+		},
+		{
+			Args: []string{"{}", "assert.stack"},
+		},
+		{
+			Args: []string{"0.1", "0.2", "+", "'a'", "{0.3 'a'}", "assert.stack"},
+			Want: []string{"0.3", "'a'"},
+		},
+		{
+			Args:    []string{"1", "2", "{1}", "assert.stack"},
+			Want:    []string{"1", "2"},
+			WantErr: rpn.ErrAssertion,
+		},
+		{
+			Args:    []string{"1", "2", "{2 1}", "assert.stack"},
+			Want:    []string{"1", "2"},
+			WantErr: rpn.ErrAssertion,
+		},
+		{
+			Args:    []string{"1", "{foo}", "assert.stack"},
+			Want:    []string{"1"},
+			WantErr: rpn.ErrSyntax,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
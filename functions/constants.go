@@ -0,0 +1,69 @@
+package functions
+
+import (
+	"mattwach/rpngo/rpn"
+	"strconv"
+)
+
+// constant is a read-only named value.  Constants are registered as
+// commands under rpn.ConstPrefix, e.g. const.c pushes the speed of light,
+// so they do not hide short names like c and can not be assigned.
+type constant struct {
+	name  string
+	value float64
+	units string
+	help  string
+}
+
+// CODATA 2018 values
+var constants = []constant{
+	{"pi", 3.141592653589793, "", "Ratio of a circle's circumference to its diameter"},
+	{"e", 2.718281828459045, "", "Euler's number, the base of natural logarithms"},
+	{"c", 299792458, "m/s", "Speed of light in a vacuum"},
+	{"g", 9.80665, "m/s^2", "Standard acceleration of gravity"},
+	{"G", 6.67430e-11, "m^3/kg*s^2", "Newtonian constant of gravitation"},
+	{"h", 6.62607015e-34, "J*s", "Planck constant"},
+	{"hbar", 1.054571817e-34, "J*s", "Reduced Planck constant (h / 2pi)"},
+	{"k", 1.380649e-23, "J/K", "Boltzmann constant"},
+	{"qe", 1.602176634e-19, "C", "Elementary charge"},
+	{"NA", 6.02214076e23, "1/mol", "Avogadro constant"},
+	{"R", 8.314462618, "J/mol*K", "Molar gas constant"},
+	{"F", 96485.33212, "C/mol", "Faraday constant"},
+	{"me", 9.1093837015e-31, "kg", "Electron mass"},
+	{"mp", 1.67262192369e-27, "kg", "Proton mass"},
+	{"eps0", 8.8541878128e-12, "F/m", "Vacuum electric permittivity"},
+	{"mu0", 1.25663706212e-6, "N/A^2", "Vacuum magnetic permeability"},
+	{"sigma", 5.670374419e-8, "W/m^2*K^4", "Stefan-Boltzmann constant"},
+}
+
+const constantsHelp = "Constants push a read-only value to the stack, annotated\n" +
+	"with its units.  Example: 1 const.c sq * # E = mc^2 for 1 kg\n" +
+	"\n"
+
+func registerConstants(r *rpn.RPN) {
+	help := constantsHelp
+	for i := range constants {
+		c := &constants[i]
+		name := rpn.ConstPrefix + c.name
+		r.Register(name, c.push, rpn.CatConst, c.helpText())
+		_ = r.SetSignature(name, " -- x:num")
+		help += "  " + name + ": " + c.help + "\n"
+	}
+	r.RegisterConceptHelp(map[string]string{"constants": help + "See Also: variables"})
+}
+
+func (c *constant) push(r *rpn.RPN) error {
+	f := rpn.RealFrame(c.value)
+	if len(c.units) > 0 {
+		f.Annotate("`" + c.units)
+	}
+	return r.PushFrame(f)
+}
+
+func (c *constant) helpText() string {
+	s := c.help + "\n" + strconv.FormatFloat(c.value, 'g', -1, 64)
+	if len(c.units) > 0 {
+		s += " " + c.units
+	}
+	return s
+}
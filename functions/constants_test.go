@@ -0,0 +1,72 @@
+package functions
+
+import (
+	"mattwach/rpngo/rpn"
+	"strings"
+	"testing"
+)
+
+func TestConstants(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"const.pi"},
+			Want: []string{"3.141592653589793"},
+		},
+		{
+			Args: []string{"const.c"},
+			Want: []string{"299792458 `m/s"},
+		},
+		{
+			Args: []string{"const.qe"},
+			Want: []string{"1.602176634e-19 `C"},
+		},
+		{
+			Args: []string{"5", "c=", "const.c", "$c"},
+			Want: []string{"299792458 `m/s", "5"},
+		},
+		{
+			Args:    []string{"5", "const.c="},
+			Want:    []string{"5"},
+			WantErr: rpn.ErrIllegalName,
+		},
+		{
+			Args:    []string{"5", "const.c<"},
+			Want:    []string{"5"},
+			WantErr: rpn.ErrIllegalName,
+		},
+		{
+			Args: []string{"const.g", "2", "*"},
+			Want: []string{"19.6133"},
+		},
+		{
+			Args: []string{"1", "const.c", "sq", "*"},
+			Want: []string{"8.987551787368176e+16"},
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestConstantsHelp(t *testing.T) {
+	var r rpn.RPN
+	r.Init(256)
+	RegisterAll(&r)
+	var got string
+	r.Print = func(msg string) {
+		got = got + msg
+	}
+	if err := r.Exec("constants?"); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	for _, c := range constants {
+		if !strings.Contains(got, "  const."+c.name+": "+c.help) {
+			t.Errorf("constants? is missing %v", c.name)
+		}
+	}
+	got = ""
+	if err := r.Exec("const.h?"); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if !strings.Contains(got, "Planck constant\n6.62607015e-34 J*s") {
+		t.Errorf("const.h? got %v", got)
+	}
+}
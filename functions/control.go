@@ -1,9 +1,6 @@
 package functions
 
-import (
-	"mattwach/rpngo/parse"
-	"mattwach/rpngo/rpn"
-)
+import "mattwach/rpngo/rpn"
 
 const IfHelp = "Pops action, then val. Executes val if cond is true.\n" +
 	"Example: 4 3 > {\"a is greater than b\" printlnx} if"
@@ -22,7 +19,7 @@ func If(r *rpn.RPN) error {
 		return err
 	}
 	if cond {
-		if err := parse.Fields(f.String(false), r.Exec); err != nil {
+		if err := r.ExecMacro(f.String(false)); err != nil {
 			return err
 		}
 	}
@@ -50,11 +47,11 @@ func ifelse(r *rpn.RPN) error {
 		return err
 	}
 	if cond {
-		if err := parse.Fields(ifv.String(false), r.Exec); err != nil {
+		if err := r.ExecMacro(ifv.String(false)); err != nil {
 			return err
 		}
 	} else {
-		if err := parse.Fields(elsev.String(false), r.Exec); err != nil {
+		if err := r.ExecMacro(elsev.String(false)); err != nil {
 			return err
 		}
 	}
@@ -62,32 +59,48 @@ func ifelse(r *rpn.RPN) error {
 }
 
 const forHelp = "Executes the head of the stack in a loop until a value < is found\n" +
-	"Example: 1 'c 1 + c 50 <' for # put 1 to 50 on the stack"
+	"Example: 1 'c 1 + c 50 <' for # put 1 to 50 on the stack\n" +
+	"See Also: while, times, break, continue"
 
-// avoid allocating fields on the stack (for can be nested though)
-var forFields []string
-var forFieldsStart []int
+// compileLoopMacro compiles the macro in f so that it is only parsed once
+func compileLoopMacro(r *rpn.RPN, f rpn.Frame) (*rpn.Macro, error) {
+	if !f.IsString() {
+		return nil, rpn.ErrExpectedAString
+	}
+	return r.CompileMacro(f.UnsafeString())
+}
+
+// execLoopBody runs one iteration of a loop body.  done is true if the loop
+// should stop, either because of break or an error.
+func execLoopBody(r *rpn.RPN, body *rpn.Macro) (done bool, err error) {
+	err = r.ExecCompiled(body)
+	switch err {
+	case nil, rpn.ErrContinue:
+		return false, nil
+	case rpn.ErrBreak:
+		return true, nil
+	}
+	return true, err
+}
 
 func forFn(r *rpn.RPN) error {
-	forFieldsStart = append(forFieldsStart, len(forFields))
-	defer func() { forFieldsStart = forFieldsStart[:len(forFieldsStart)-1] }()
 	mf, err := r.PopFrame()
 	if err != nil {
 		return err
 	}
-	if !mf.IsString() {
-		return rpn.ErrExpectedAString
-	}
-	macro := mf.UnsafeString()
-	addField := func(t string) error {
-		forFields = append(forFields, t)
-		return nil
-	}
-	if err := parse.Fields(macro, addField); err != nil {
+	body, err := compileLoopMacro(r, mf)
+	if err != nil {
 		return err
 	}
 	for {
-		if err := r.ExecSlice(forFields[forFieldsStart[len(forFieldsStart)-1]:]); err != nil {
+		err := r.ExecCompiled(body)
+		if err == rpn.ErrBreak {
+			break
+		}
+		if err == rpn.ErrContinue {
+			continue
+		}
+		if err != nil {
 			return err
 		}
 		cf, err := r.PopFrame()
@@ -104,3 +117,108 @@ func forFn(r *rpn.RPN) error {
 	}
 	return nil
 }
+
+const whileHelp = "Pops body, then cond.  Executes cond, which must leave a boolean " +
+	"on the stack, then executes body if it's true.  Repeats until cond is false.\n" +
+	"Example: 1 x= {$x 50 <=} {$x $x 1 + x=} while # put 1 to 50 on the stack\n" +
+	"See Also: for, times, break, continue"
+
+func while(r *rpn.RPN) error {
+	if len(r.Frames) < 2 {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	bodyf := r.Frames[len(r.Frames)-1]
+	condf := r.Frames[len(r.Frames)-2]
+	cond, err := compileLoopMacro(r, condf)
+	if err != nil {
+		return err
+	}
+	body, err := compileLoopMacro(r, bodyf)
+	if err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-2]
+	for {
+		if err := r.ExecCompiled(cond); err != nil {
+			return err
+		}
+		cf, err := r.PopFrame()
+		if err != nil {
+			return err
+		}
+		ok, err := cf.Bool()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if done, err := execLoopBody(r, body); done {
+			return err
+		}
+	}
+	return nil
+}
+
+const timesHelp = "Pops body, a variable name, then n.  Executes body n times, with the " +
+	"variable set to 0, 1, ... n-1.  The variable's previous value is restored " +
+	"when the loop ends.\n" +
+	"Example: 10 'i' {$i sq} times # push the squares of 0 to 9\n" +
+	"See Also: for, while, break, continue"
+
+func times(r *rpn.RPN) error {
+	if len(r.Frames) < 3 {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	bodyf := r.Frames[len(r.Frames)-1]
+	namef := r.Frames[len(r.Frames)-2]
+	if !namef.IsString() {
+		return rpn.ErrExpectedAString
+	}
+	name := namef.UnsafeString()
+	n, err := r.Frames[len(r.Frames)-3].Int()
+	if err != nil {
+		return err
+	}
+	body, err := compileLoopMacro(r, bodyf)
+	if err != nil {
+		return err
+	}
+	depth := r.VariableDepth(name)
+	if err := r.PushLocal(name, rpn.RealFrame(0)); err != nil {
+		return err
+	}
+	// The body can pop or push values of the loop variable, so the
+	// variable is put back to its depth before the loop rather than
+	// popped.  Otherwise, the caller's value could be changed.
+	defer r.TruncateVariable(name, depth)
+	r.Frames = r.Frames[:len(r.Frames)-3]
+	for i := int64(0); i < n; i++ {
+		r.TruncateVariable(name, depth)
+		if err := r.PushLocal(name, rpn.RealFrame(float64(i))); err != nil {
+			return err
+		}
+		if done, err := execLoopBody(r, body); done {
+			return err
+		}
+	}
+	return nil
+}
+
+const breakHelp = "Exits the innermost for, while, or times loop.\n" +
+	"Example: 0 x= {$x 1 + x= $x 5 > {break} if $x true} for # 1 2 3 4 5\n" +
+	"See Also: continue"
+
+func breakFn(r *rpn.RPN) error {
+	return rpn.ErrBreak
+}
+
+const continueHelp = "Skips the rest of the current iteration of the innermost " +
+	"for, while, or times loop.  In a for loop, execution continues as if " +
+	"true was returned.\n" +
+	"Example: 10 'i' {$i 2 % 0 = {continue} if $i} times # odd numbers\n" +
+	"See Also: break"
+
+func continueFn(r *rpn.RPN) error {
+	return rpn.ErrContinue
+}
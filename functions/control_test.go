@@ -84,3 +84,130 @@ func TestFor(t *testing.T) {
 	}
 	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
 }
+
+func TestForBreakContinue(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"0", "x=", "{$x 1 + x= $x 5 > {break} if $x true}", "for"},
+			Want: []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			Args: []string{"0", "x=", "{$x 1 + x= $x 2 % 0 = {continue} if $x $x 5 <}", "for"},
+			Want: []string{"1", "3", "5"},
+		},
+		{
+			// break unwinds through @ and try
+			Args: []string{"{break}", "b=", "0", "x=", "{$x 1 + x= {$x 3 = {@b} if} {} try $x true}", "for"},
+			Want: []string{"1", "2"},
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestWhile(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args:    []string{"{true}", "while"},
+			Want:    []string{"{true}"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"1", "{true}", "while"},
+			Want:    []string{"1", "{true}"},
+			WantErr: rpn.ErrExpectedAString,
+		},
+		{
+			Args: []string{"{false}", "{1}", "while"},
+		},
+		{
+			Args: []string{"1", "x=", "{$x 5 <=}", "{$x $x 1 + x=}", "while"},
+			Want: []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			Args: []string{"1", "x=", "{true}", "{$x 3 > {break} if $x $x 1 + x=}", "while"},
+			Want: []string{"1", "2", "3"},
+		},
+		{
+			Args: []string{"0", "x=", "{$x 5 <}", "{$x 1 + x= $x 2 = {continue} if $x}", "while"},
+			Want: []string{"1", "3", "4", "5"},
+		},
+		{
+			Args:    []string{"{1}", "{2}", "while"},
+			WantErr: rpn.ErrExpectedABoolean,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestTimes(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args:    []string{"'i'", "{$i}", "times"},
+			Want:    []string{"'i'", "{$i}"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"3", "1", "{$i}", "times"},
+			Want:    []string{"3", "1", "{$i}"},
+			WantErr: rpn.ErrExpectedAString,
+		},
+		{
+			Args:    []string{"3", "'1'", "{$i}", "times"},
+			Want:    []string{"3", "'1'", "{$i}"},
+			WantErr: rpn.ErrIllegalName,
+		},
+		{
+			Args: []string{"0", "'i'", "{$i}", "times"},
+		},
+		{
+			Args: []string{"4", "'i'", "{$i sq}", "times"},
+			Want: []string{"0", "1", "4", "9"},
+		},
+		{
+			Args: []string{"7", "i=", "2", "'i'", "{$i}", "times", "$i"},
+			Want: []string{"0", "1", "7"},
+		},
+		{
+			// popping the loop variable does not change the caller's i
+			Args: []string{"7", "i=", "3", "'i'", "{i>}", "times", "$i"},
+			Want: []string{"0", "1", "2", "7"},
+		},
+		{
+			// the loop variable can be used in the sandbox
+			Args: []string{"7", "i=", "{3 'i' {$i} times}", "sandbox", "$i"},
+			Want: []string{"0", "1", "2", "7"},
+		},
+		{
+			Args: []string{"2", "'i'", "{2 'j' {$i 10 * $j +} times}", "times"},
+			Want: []string{"0", "1", "10", "11"},
+		},
+		{
+			Args: []string{"10", "'i'", "{$i 3 = {break} if $i}", "times"},
+			Want: []string{"0", "1", "2"},
+		},
+		{
+			Args: []string{"6", "'i'", "{$i 2 % 0 = {continue} if $i}", "times"},
+			Want: []string{"1", "3", "5"},
+		},
+		{
+			Args:    []string{"2", "'i'", "{$i 1 = {foo} if $i}", "times", "$i"},
+			Want:    []string{"0"},
+			WantErr: rpn.ErrSyntax,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestBreakContinueOutsideLoop(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args:    []string{"break"},
+			WantErr: rpn.ErrBreak,
+		},
+		{
+			Args:    []string{"continue"},
+			WantErr: rpn.ErrContinue,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
@@ -5,7 +5,6 @@ import (
 	"math"
 	"math/cmplx"
 	"math/rand"
-	"mattwach/rpngo/parse"
 	"mattwach/rpngo/rpn"
 )
 
@@ -192,7 +191,7 @@ func exec(r *rpn.RPN) error {
 	if !f.IsString() {
 		return rpn.ErrExpectedAString
 	}
-	return parse.Fields(f.UnsafeString(), r.Exec)
+	return r.ExecMacro(f.UnsafeString())
 }
 
 const randHelp = "Pushes a random number between 0 and 1"
@@ -1,7 +1,6 @@
 package functions
 
 import (
-	"errors"
 	"mattwach/rpngo/parse"
 	"mattwach/rpngo/rpn"
 )
@@ -19,15 +18,19 @@ func try(r *rpn.RPN) error {
 	if err != nil {
 		return err
 	}
-	err = parse.Fields(tryv.String(false), r.Exec)
+	err = r.ExecMacro(tryv.String(false))
 	if err == nil {
 		return nil
 	}
+	if _, ok := err.(parse.PassThroughError); ok {
+		// break and continue are not errors, they need to reach their loop
+		return err
+	}
 	err = r.PushFrame(rpn.StringFrame(err.Error(), rpn.STRING_SINGLEQ_FRAME))
 	if err != nil {
 		return err
 	}
-	return parse.Fields(errorv.String(false), r.Exec)
+	return r.ExecMacro(errorv.String(false))
 }
 
 const errorHelp = "Pops a frame and returns it as an error"
@@ -37,5 +40,5 @@ func errorFn(r *rpn.RPN) error {
 	if err != nil {
 		return err
 	}
-	return errors.New(f.String(false))
+	return rpn.UserError(f.String(false))
 }
@@ -31,6 +31,18 @@ func TestTryAndError(t *testing.T) {
 			Args: []string{"{'foo' error}", "{}", "try"},
 			Want: []string{"''foo' ->error<-: foo'"},
 		},
+		{
+			Args: []string{"{2 0 /}", "{0/ err.cause err.kind}", "try"},
+			Want: []string{"'divide by zero'", "'divide_by_zero'"},
+		},
+		{
+			Args: []string{"{{'bad value' error} if}", "f=", "{true @f}", "{0/ err.cause err.kind}", "try"},
+			Want: []string{"'bad value'", "'user'"},
+		},
+		{
+			Args: []string{"{+}", "{0/ err.kind}", "try"},
+			Want: []string{"'stack_empty'"},
+		},
 	}
 	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
 }
@@ -1,8 +1,7 @@
 package functions
 
 import (
-	"mattwach/rpngo/elog"
-	"mattwach/rpngo/parse"
+	"fmt"
 	"mattwach/rpngo/rpn"
 )
 
@@ -17,39 +16,152 @@ const filterHelp = "Copies each value from the bottom to the top of the stack "
 	"See Also: filterm, filtern, filtermn"
 
 func filter(r *rpn.RPN) error {
-	fn, err := r.PopFrame()
+	if len(r.Frames) == 0 {
+		return rpn.ErrStackEmpty
+	}
+	fn := r.Frames[len(r.Frames)-1]
+	return filterFrames(r, fn, 1, len(r.Frames)-1, 1)
+}
+
+const filtermHelp = "Like filter, but copies m values to the top of the stack " +
+	"for each call.  Pops m, then the filter function.  The number of values " +
+	"on the stack must be a multiple of m.\n" +
+	"\n" +
+	"Examples:\n" +
+	"{*} 2 filterm  # multiply x/y pairs\n" +
+	"{1> 0/} 2 filterm  # keep only the first value of each pair\n" +
+	"\n" +
+	"See Also: filter, filtern, filtermn"
+
+func filterm(r *rpn.RPN) error {
+	fn, m, n, err := peekFilterArgs(r, true, false)
 	if err != nil {
 		return err
 	}
-	endIdx := len(r.Frames)
-	if endIdx == 0 {
-		return nil
+	return filterFrames(r, fn, m, n, 2)
+}
+
+const filternHelp = "Like filter, but only applies to the top n values of the " +
+	"stack.  Values below are left unchanged.  Pops n, then the filter function.\n" +
+	"\n" +
+	"Examples:\n" +
+	"{2 *} 3 filtern  # multiply the top 3 values by 2\n" +
+	"\n" +
+	"See Also: filter, filterm, filtermn"
+
+func filtern(r *rpn.RPN) error {
+	fn, _, n, err := peekFilterArgs(r, false, true)
+	if err != nil {
+		return err
+	}
+	return filterFrames(r, fn, 1, n, 2)
+}
+
+const filtermnHelp = "Combines filterm and filtern: copies m values to the top " +
+	"of the stack for each call and only applies to the top n values.  Pops n, " +
+	"m, then the filter function.  n must be a multiple of m.\n" +
+	"\n" +
+	"Examples:\n" +
+	"{+} 2 4 filtermn  # add the top two pairs of values\n" +
+	"\n" +
+	"See Also: filter, filterm, filtern"
+
+func filtermn(r *rpn.RPN) error {
+	fn, m, n, err := peekFilterArgs(r, true, true)
+	if err != nil {
+		return err
+	}
+	return filterFrames(r, fn, m, n, 3)
+}
+
+// peekFilterArgs validates the filter function along with the optional m
+// and n counts.  They are left on the stack for filterFrames to pop.
+func peekFilterArgs(r *rpn.RPN, hasM bool, hasN bool) (fn rpn.Frame, m int, n int, err error) {
+	nargs := 1
+	if hasM {
+		nargs++
 	}
-	elog.Heap("alloc: functions/filter.go:117: fields := make([]string, 0, 16)")
-	fields := make([]string, 0, 16) // object allocated on the heap: escapes at line 117
-	addField := func(t string) error {
-		fields = append(fields, t)
+	if hasN {
+		nargs++
+	}
+	if len(r.Frames) < nargs {
+		return fn, 0, 0, rpn.ErrNotEnoughStackFrames
+	}
+	args := r.Frames[len(r.Frames)-nargs:]
+	fn = args[0]
+	available := len(r.Frames) - nargs
+	m = 1
+	n = available
+	if hasM {
+		v, err := args[1].Int()
+		if err != nil {
+			return fn, 0, 0, err
+		}
+		if v < 1 {
+			return fn, 0, 0, rpn.ErrIllegalValue
+		}
+		m = int(v)
+	}
+	if hasN {
+		v, err := args[nargs-1].Int()
+		if err != nil {
+			return fn, 0, 0, err
+		}
+		if v < 0 {
+			return fn, 0, 0, rpn.ErrIllegalValue
+		}
+		if int(v) > available {
+			return fn, 0, 0, rpn.ErrNotEnoughStackFrames
+		}
+		n = int(v)
+	}
+	if n%m != 0 {
+		return fn, 0, 0, rpn.ErrIllegalValue
+	}
+	return fn, m, n, nil
+}
+
+// filterFrames pops nargs arguments, calls fn for every group of m values
+// in the top n values of the stack, then removes the original n values.
+func filterFrames(r *rpn.RPN, fn rpn.Frame, m int, n int, nargs int) error {
+	var args [3]rpn.Frame
+	copy(args[:], r.Frames[len(r.Frames)-nargs:])
+	r.Frames = r.Frames[:len(r.Frames)-nargs]
+	endIdx := len(r.Frames)
+	startIdx := endIdx - n
+	if n == 0 {
 		return nil
 	}
-	if err := parse.Fields(fn.String(false), addField); err != nil {
+	macro, err := r.CompileMacro(fn.String(false))
+	if err != nil {
 		return err
 	}
-	for i := 0; i < endIdx; i++ {
-		if i >= len(r.Frames) {
+	for i := startIdx; i < endIdx; i += m {
+		if i+m > len(r.Frames) {
 			return rpn.ErrNotEnoughStackFrames
 		}
-		if err := r.PushFrame(r.Frames[i]); err != nil {
-			return err
+		for j := i; j < i+m; j++ {
+			if err := r.PushFrame(r.Frames[j]); err != nil {
+				return err
+			}
 		}
-		if err := r.ExecSlice(fields); err != nil {
+		err := r.ExecCompiled(macro)
+		if (err == rpn.ErrBreak) || (err == rpn.ErrContinue) {
+			// filter is not a loop, so stop with the stack as it was
+			if len(r.Frames) >= endIdx {
+				r.Frames = append(r.Frames[:endIdx], args[:nargs]...)
+			}
+			return fmt.Errorf("filter: %w", err)
+		}
+		if err != nil {
 			return err
 		}
 	}
-	newSize := len(r.Frames) - endIdx
-	if newSize <= 0 {
-		r.Frames = r.Frames[:0]
+	newSize := len(r.Frames) - n
+	if newSize <= startIdx {
+		r.Frames = r.Frames[:newSize]
 	} else {
-		copy(r.Frames, r.Frames[endIdx:])
+		copy(r.Frames[startIdx:], r.Frames[endIdx:])
 		r.Frames = r.Frames[:newSize]
 	}
 	return nil
@@ -38,6 +38,111 @@ func TestFilter(t *testing.T) {
 			Want:    []string{"1"},
 			WantErr: rpn.ErrNotEnoughStackFrames,
 		},
+		{
+			Args:    []string{"1", "2", "3", "{$0 2 = {break} if sq}", "filter"},
+			Want:    []string{"1", "2", "3", "{$0 2 = {break} if sq}"},
+			WantErr: rpn.ErrBreak,
+		},
+		{
+			Args:    []string{"1", "2", "{continue}", "filter"},
+			Want:    []string{"1", "2", "{continue}"},
+			WantErr: rpn.ErrContinue,
+		},
+		{
+			// filter stops break from reaching the loop around it
+			Args:    []string{"2", "'i'", "{1 {break} filter}", "times"},
+			Want:    []string{"1", "{break}"},
+			WantErr: rpn.ErrBreak,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestFilterM(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args:    []string{"filterm"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args: []string{"{*}", "2", "filterm"},
+		},
+		{
+			Args: []string{"1", "2", "3", "4", "{*}", "2", "filterm"},
+			Want: []string{"2", "12"},
+		},
+		{
+			Args: []string{"1", "2", "3", "4", "5", "6", "{0/ 0/}", "3", "filterm"},
+			Want: []string{"1", "4"},
+		},
+		{
+			Args: []string{"1", "2", "3", "{sq}", "1", "filterm"},
+			Want: []string{"1", "4", "9"},
+		},
+		{
+			Args:    []string{"1", "2", "3", "{*}", "2", "filterm"},
+			Want:    []string{"1", "2", "3", "{*}", "2"},
+			WantErr: rpn.ErrIllegalValue,
+		},
+		{
+			Args:    []string{"1", "2", "{*}", "0", "filterm"},
+			Want:    []string{"1", "2", "{*}", "0"},
+			WantErr: rpn.ErrIllegalValue,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestFilterN(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args:    []string{"filtern"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args: []string{"1", "2", "{2 *}", "0", "filtern"},
+			Want: []string{"1", "2"},
+		},
+		{
+			Args: []string{"1", "2", "3", "4", "5", "{2 *}", "2", "filtern"},
+			Want: []string{"1", "2", "3", "8", "10"},
+		},
+		{
+			Args: []string{"1", "200", "3", "400", "{$0 100 >= {0/} if}", "3", "filtern"},
+			Want: []string{"1", "3"},
+		},
+		{
+			Args: []string{"1", "2", "3", "{2 *}", "3", "filtern"},
+			Want: []string{"2", "4", "6"},
+		},
+		{
+			Args:    []string{"1", "2", "{2 *}", "3", "filtern"},
+			Want:    []string{"1", "2", "{2 *}", "3"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestFilterMN(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args:    []string{"filtermn"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args: []string{"1", "2", "3", "4", "5", "{+}", "2", "4", "filtermn"},
+			Want: []string{"1", "5", "9"},
+		},
+		{
+			Args: []string{"1", "2", "3", "4", "5", "{sq}", "1", "2", "filtermn"},
+			Want: []string{"1", "2", "3", "16", "25"},
+		},
+		{
+			Args:    []string{"1", "2", "3", "4", "5", "{+}", "2", "3", "filtermn"},
+			Want:    []string{"1", "2", "3", "4", "5", "{+}", "2", "3"},
+			WantErr: rpn.ErrIllegalValue,
+		},
 	}
 	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
 }
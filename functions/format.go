@@ -0,0 +1,137 @@
+package functions
+
+import (
+	"fmt"
+	"mattwach/rpngo/rpn"
+	"strings"
+)
+
+// fmtVerbs are the directive letters that fmt accepts
+const fmtVerbs = "dxXobfeEgGs%"
+
+const fmtHelp = "Pops a template string, fills its directives with values " +
+	"from the stack and pushes the result.  The first directive uses the " +
+	"deepest value.  A directive is %[flags][width][.precision]verb where the " +
+	"flags are - (left justify), 0 (pad with zeros), + (always show the sign) " +
+	"and space (a space for positive numbers).  The verbs are:\n" +
+	"  d     integer\n" +
+	"  x, X  hexidecimal integer\n" +
+	"  o     octal integer\n" +
+	"  b     binary integer\n" +
+	"  f     fixed point\n" +
+	"  e, E  scientific notation\n" +
+	"  g, G  the shorter of f and e\n" +
+	"  s     any value, as shown by str\n" +
+	"  %     a percent sign, does not use a value\n" +
+	"Example: 3.14159 255 'V=%.3f A=%x' fmt # 'V=3.142 A=ff'\n" +
+	"See Also: println, str"
+
+func fmtFn(r *rpn.RPN) error {
+	tf, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	if !tf.IsString() {
+		return rpn.ErrExpectedAString
+	}
+	tmpl := tf.UnsafeString()
+	n := 0
+	err = fmtDirectives(tmpl, func(_, d string) error {
+		if usesValue(d) {
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(r.Frames) < n+1 {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	values := r.Frames[len(r.Frames)-1-n : len(r.Frames)-1]
+	var sb strings.Builder
+	err = fmtDirectives(tmpl, func(text, d string) error {
+		sb.WriteString(text)
+		if len(d) == 0 {
+			return nil
+		}
+		if !usesValue(d) {
+			sb.WriteByte('%')
+			return nil
+		}
+		s, err := fmtValue(d, &values[0])
+		if err != nil {
+			return err
+		}
+		values = values[1:]
+		sb.WriteString(s)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1-n]
+	return r.PushFrame(rpn.StringFrame(sb.String(), rpn.STRING_SINGLEQ_FRAME))
+}
+
+// fmtDirectives calls fn with the text before each directive and the
+// directive itself, e.g. %.3f.  The text after the last directive is passed
+// with an empty directive.
+func fmtDirectives(tmpl string, fn func(text, directive string) error) error {
+	for {
+		i := strings.IndexByte(tmpl, '%')
+		if i < 0 {
+			return fn(tmpl, "")
+		}
+		j := i + 1
+		for (j < len(tmpl)) && (strings.IndexByte("-+ 0", tmpl[j]) >= 0) {
+			j++
+		}
+		j = skipDigits(tmpl, j)
+		if (j < len(tmpl)) && (tmpl[j] == '.') {
+			j = skipDigits(tmpl, j+1)
+		}
+		if j >= len(tmpl) {
+			return fmt.Errorf("%w: bad directive %s", rpn.ErrSyntax, tmpl[i:])
+		}
+		if strings.IndexByte(fmtVerbs, tmpl[j]) < 0 {
+			return fmt.Errorf("%w: bad directive %s", rpn.ErrSyntax, tmpl[i:j+1])
+		}
+		if err := fn(tmpl[:i], tmpl[i:j+1]); err != nil {
+			return err
+		}
+		tmpl = tmpl[j+1:]
+	}
+}
+
+func skipDigits(s string, i int) int {
+	for (i < len(s)) && (s[i] >= '0') && (s[i] <= '9') {
+		i++
+	}
+	return i
+}
+
+func usesValue(directive string) bool {
+	return (len(directive) > 0) && (directive[len(directive)-1] != '%')
+}
+
+func fmtValue(directive string, f *rpn.Frame) (string, error) {
+	switch directive[len(directive)-1] {
+	case 'd', 'x', 'X', 'o', 'b':
+		v, err := f.Int()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(directive, v), nil
+	case 'f', 'e', 'E', 'g', 'G':
+		v, err := f.Complex()
+		if err != nil {
+			return "", err
+		}
+		if imag(v) != 0 {
+			return fmt.Sprintf(directive, v), nil
+		}
+		return fmt.Sprintf(directive, real(v)), nil
+	}
+	return fmt.Sprintf(directive, f.String(false)), nil
+}
@@ -0,0 +1,73 @@
+package functions
+
+import (
+	"mattwach/rpngo/rpn"
+	"testing"
+)
+
+func TestFmt(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args:    []string{"fmt"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"5", "fmt"},
+			WantErr: rpn.ErrExpectedAString,
+			Want:    []string{"5"},
+		},
+		{
+			Args: []string{"'plain'", "fmt"},
+			Want: []string{"'plain'"},
+		},
+		{
+			Args: []string{"3.14159", "255", "'V=%.3f A=%x'", "fmt"},
+			Want: []string{"'V=3.142 A=ff'"},
+		},
+		{
+			Args: []string{"1", "10d", "-3", "7", "'%d %5d|%-5d|%05d'", "fmt"},
+			Want: []string{"'1    10|-3   |00007'"},
+		},
+		{
+			Args: []string{"255", "255", "255", "'%X %o %b'", "fmt"},
+			Want: []string{"'FF 377 11111111'"},
+		},
+		{
+			Args: []string{"1234.5", "2", "'%.2e %+g'", "fmt"},
+			Want: []string{"'1.23e+03 +2'"},
+		},
+		{
+			Args: []string{"1+2i", "'%.1f'", "fmt"},
+			Want: []string{"'(1.0+2.0i)'"},
+		},
+		{
+			Args: []string{"'ab'", "true", "5", "'[%4s] %s %s'", "fmt"},
+			Want: []string{"'[  ab] true 5'"},
+		},
+		{
+			Args: []string{"50", "'%d%%'", "fmt"},
+			Want: []string{"'50%'"},
+		},
+		{
+			Args:    []string{"5", "'%d %d'", "fmt"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+			Want:    []string{"5", "'%d %d'"},
+		},
+		{
+			Args:    []string{"'x'", "'%d'", "fmt"},
+			WantErr: rpn.ErrExpectedANumber,
+			Want:    []string{"'x'", "'%d'"},
+		},
+		{
+			Args:    []string{"5", "'%q'", "fmt"},
+			WantErr: rpn.ErrSyntax,
+			Want:    []string{"5", "'%q'"},
+		},
+		{
+			Args:    []string{"5", "'%5'", "fmt"},
+			WantErr: rpn.ErrSyntax,
+			Want:    []string{"5", "'%5'"},
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
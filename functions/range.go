@@ -0,0 +1,126 @@
+package functions
+
+import (
+	"math"
+	"mattwach/rpngo/rpn"
+)
+
+// rangeTolerance allows for rounding errors when stop is a multiple of a
+// non-integer step, e.g. 0 1 0.1 range
+const rangeTolerance = 1e-9
+
+const rangeHelp = "Pops step, stop and start and pushes start, start+step, ... " +
+	"up to and including stop.  If a variable name is given first, the " +
+	"values are pushed to that variable's stack instead.  If start, stop " +
+	"and step are all integers, the values are integers.\n" +
+	"Examples:\n" +
+	"  1 10 1 range  # push 1 to 10\n" +
+	"  10 0 -2 range  # push 10 8 6 4 2 0\n" +
+	"  0 1 0.25 'x' range  # push 0 0.25 0.5 0.75 1 to $x\n" +
+	"See Also: times, sum, reduce"
+
+func rangeFn(r *rpn.RPN) error {
+	nargs := 3
+	var name string
+	if len(r.Frames) > 0 {
+		if f := r.Frames[len(r.Frames)-1]; f.IsString() {
+			name = f.UnsafeString()
+			nargs++
+		}
+	}
+	if len(r.Frames) < nargs {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	args := r.Frames[len(r.Frames)-nargs:]
+	values, err := rangeValues(args[0], args[1], args[2], r.MaxStackDepth())
+	if err != nil {
+		return err
+	}
+	if len(name) == 0 {
+		if len(r.Frames)-nargs+len(values) > r.MaxStackDepth() {
+			return rpn.ErrStackFull
+		}
+		r.Frames = append(r.Frames[:len(r.Frames)-nargs], values...)
+		return nil
+	}
+	for _, v := range values {
+		if err := r.PushVariable(name, v); err != nil {
+			return err
+		}
+	}
+	r.Frames = r.Frames[:len(r.Frames)-nargs]
+	return nil
+}
+
+// rangeValues returns the values from start to stop.  An error is returned
+// if there would be more than max values.
+func rangeValues(startf, stopf, stepf rpn.Frame, max int) ([]rpn.Frame, error) {
+	start, err := startf.Real()
+	if err != nil {
+		return nil, err
+	}
+	stop, err := stopf.Real()
+	if err != nil {
+		return nil, err
+	}
+	step, err := stepf.Real()
+	if err != nil {
+		return nil, err
+	}
+	if step == 0 {
+		return nil, rpn.ErrIllegalValue
+	}
+	n := math.Floor((stop-start)/step+rangeTolerance) + 1
+	if n < 0 {
+		n = 0
+	}
+	if n > float64(max) {
+		return nil, rpn.ErrStackFull
+	}
+	values := make([]rpn.Frame, int(n))
+	if startf.IsInt() && stopf.IsInt() && stepf.IsInt() {
+		for i := range values {
+			values[i] = rpn.IntFrameCloneType(int64(start)+int64(i)*int64(step), startf)
+		}
+		return values, nil
+	}
+	scale := decimalScale(start, step)
+	if scale > 0 {
+		// count in whole units so that 0 1 0.1 range gives 0.6, not
+		// 0.6000000000000001
+		istart := math.Round(start * scale)
+		istep := math.Round(step * scale)
+		for i := range values {
+			values[i] = rpn.RealFrame((istart + float64(i)*istep) / scale)
+		}
+		return values, nil
+	}
+	for i := range values {
+		values[i] = rpn.RealFrame(start + float64(i)*step)
+	}
+	return values, nil
+}
+
+// maxRangeDecimals is the most decimal places that decimalScale will try
+const maxRangeDecimals = 9
+
+// decimalScale returns the power of 10 that turns all of vals into whole
+// numbers, or 0 if there is none.
+func decimalScale(vals ...float64) float64 {
+	scale := 1.0
+	for d := 0; d <= maxRangeDecimals; d++ {
+		whole := true
+		for _, v := range vals {
+			x := v * scale
+			if (math.Abs(x) > 1e15) || (math.Abs(x-math.Round(x)) > rangeTolerance) {
+				whole = false
+				break
+			}
+		}
+		if whole {
+			return scale
+		}
+		scale *= 10
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package functions
+
+import (
+	"mattwach/rpngo/rpn"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"1", "5", "1", "range"},
+			Want: []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			Args: []string{"10", "0", "-3", "range"},
+			Want: []string{"10", "7", "4", "1"},
+		},
+		{
+			Args: []string{"0", "1", "0.25", "range"},
+			Want: []string{"0", "0.25", "0.5", "0.75", "1"},
+		},
+		{
+			Args: []string{"0", "1", "0.1", "range"},
+			Want: []string{"0", "0.1", "0.2", "0.3", "0.4", "0.5", "0.6", "0.7", "0.8", "0.9", "1"},
+		},
+		{
+			Args: []string{"1", "2", "0.7853981633974483", "range"},
+			Want: []string{"1", "1.785398163397448"},
+		},
+		{
+			Name: "rounding",
+			Args: []string{"0", "1", "0.1", "range", "s.size", "n=", "d", "$n"},
+			Want: []string{"11d"},
+		},
+		{
+			Name: "integers",
+			Args: []string{"1d", "3d", "1d", "range"},
+			Want: []string{"1d", "2d", "3d"},
+		},
+		{
+			Name: "empty",
+			Args: []string{"5", "1", "1", "range"},
+		},
+		{
+			Name: "variable",
+			Args: []string{"1", "3", "1", "'x'", "range", "x>", "x>", "x>"},
+			Want: []string{"3", "2", "1"},
+		},
+		{
+			Args:    []string{"1", "3", "0", "range"},
+			Want:    []string{"1", "3", "0"},
+			WantErr: rpn.ErrIllegalValue,
+		},
+		{
+			Args:    []string{"1", "3", "range"},
+			Want:    []string{"1", "3"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"1", "1000", "1", "range"},
+			Want:    []string{"1", "1000", "1"},
+			WantErr: rpn.ErrStackFull,
+		},
+		{
+			Args:    []string{"1", "3", "true", "range"},
+			Want:    []string{"1", "3", "true"},
+			WantErr: rpn.ErrExpectedANumber,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
@@ -0,0 +1,112 @@
+package functions
+
+import "mattwach/rpngo/rpn"
+
+const reduceHelp = "Pops a macro that combines two values into one, then uses " +
+	"it to combine all of the values on the stack, starting from the bottom.\n" +
+	"Examples:\n" +
+	"  1 2 3 4 {+} reduce  # 10\n" +
+	"  1 2 3 4 {-} reduce  # ((1-2)-3)-4 = -8\n" +
+	"  3 9 2 {max} reduce  # 9\n" +
+	"See Also: sum, prod, filter"
+
+func reduce(r *rpn.RPN) error {
+	if len(r.Frames) < 2 {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	fn := r.Frames[len(r.Frames)-1]
+	macro, err := r.CompileMacro(fn.String(false))
+	if err != nil {
+		return err
+	}
+	// saved also holds fn so that the stack can be restored on error
+	saved := make([]rpn.Frame, len(r.Frames))
+	copy(saved, r.Frames)
+	values := saved[:len(saved)-1]
+	r.Frames = append(r.Frames[:0], values[0])
+	for _, v := range values[1:] {
+		err := r.PushFrame(v)
+		if err == nil {
+			err = r.ExecCompiled(macro)
+		}
+		if err != nil {
+			r.Frames = append(r.Frames[:0], saved...)
+			return err
+		}
+	}
+	return nil
+}
+
+const sumHelp = "Replaces all values on the stack with their sum.  If a " +
+	"variable name is given, pushes the sum of that variable's stack instead.\n" +
+	"Examples:\n" +
+	"  1 2 3 sum  # 6\n" +
+	"  1 4 1 'x' range 'x' sum  # 10\n" +
+	"See Also: prod, reduce, range"
+
+func sum(r *rpn.RPN) error {
+	return combineAll(r, add, rpn.IntFrame(0, rpn.INTEGER_FRAME))
+}
+
+const prodHelp = "Replaces all values on the stack with their product.  If a " +
+	"variable name is given, pushes the product of that variable's stack " +
+	"instead.\n" +
+	"Examples:\n" +
+	"  1 2 3 4 prod  # 24\n" +
+	"  1 4 1 'x' range 'x' prod  # 24\n" +
+	"See Also: sum, reduce, range"
+
+func prod(r *rpn.RPN) error {
+	return combineAll(r, multiply, rpn.IntFrame(1, rpn.INTEGER_FRAME))
+}
+
+// combineAll uses op to combine either the whole stack or the variable
+// named at the head of the stack.  empty is pushed if the stack is empty.
+func combineAll(r *rpn.RPN, op func(*rpn.RPN) error, empty rpn.Frame) error {
+	if len(r.Frames) == 0 {
+		return r.PushFrame(empty)
+	}
+	if namef := r.Frames[len(r.Frames)-1]; namef.IsString() {
+		values, err := r.GetVariableStack(namef.UnsafeString())
+		if err != nil {
+			return err
+		}
+		if err := checkAllNumbers(values); err != nil {
+			return err
+		}
+		base := len(r.Frames) - 1
+		r.Frames[base] = values[0]
+		for _, v := range values[1:] {
+			err := r.PushFrame(v)
+			if err == nil {
+				err = op(r)
+			}
+			if err != nil {
+				r.Frames = append(r.Frames[:base], namef)
+				return err
+			}
+		}
+		return nil
+	}
+	if err := checkAllNumbers(r.Frames); err != nil {
+		return err
+	}
+	saved := make([]rpn.Frame, len(r.Frames))
+	copy(saved, r.Frames)
+	for len(r.Frames) > 1 {
+		if err := op(r); err != nil {
+			r.Frames = append(r.Frames[:0], saved...)
+			return err
+		}
+	}
+	return nil
+}
+
+func checkAllNumbers(frames []rpn.Frame) error {
+	for _, f := range frames {
+		if !f.IsNumber() {
+			return rpn.ErrExpectedANumber
+		}
+	}
+	return nil
+}
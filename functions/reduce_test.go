@@ -0,0 +1,85 @@
+package functions
+
+import (
+	"mattwach/rpngo/rpn"
+	"testing"
+)
+
+func TestReduce(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"1", "2", "3", "4", "{+}", "reduce"},
+			Want: []string{"10"},
+		},
+		{
+			Args: []string{"1", "2", "3", "4", "{-}", "reduce"},
+			Want: []string{"-8"},
+		},
+		{
+			Args: []string{"3", "9", "2", "{max}", "reduce"},
+			Want: []string{"9"},
+		},
+		{
+			Args: []string{"5", "{+}", "reduce"},
+			Want: []string{"5"},
+		},
+		{
+			Args:    []string{"{+}", "reduce"},
+			Want:    []string{"{+}"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"1", "{(1x) +}", "reduce"},
+			Want:    []string{"1", "{(1x) +}"},
+			WantErr: rpn.ErrIllegalName,
+		},
+		{
+			Name:    "error restores the stack",
+			Args:    []string{"4d", "2d", "0d", "{/}", "reduce"},
+			Want:    []string{"4d", "2d", "0d", "{/}"},
+			WantErr: rpn.ErrDivideByZero,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestSumProd(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"1", "2", "3", "sum"},
+			Want: []string{"6"},
+		},
+		{
+			Args: []string{"1d", "2d", "3d", "sum"},
+			Want: []string{"6d"},
+		},
+		{
+			Args: []string{"sum"},
+			Want: []string{"0d"},
+		},
+		{
+			Args: []string{"1", "2", "3", "4", "prod"},
+			Want: []string{"24"},
+		},
+		{
+			Args: []string{"prod"},
+			Want: []string{"1d"},
+		},
+		{
+			Name: "variable",
+			Args: []string{"7", "1", "4", "1", "'x'", "range", "'x'", "sum", "'x'", "prod"},
+			Want: []string{"7", "10", "24"},
+		},
+		{
+			Args:    []string{"'x'", "sum"},
+			Want:    []string{"'x'"},
+			WantErr: rpn.ErrNotFound,
+		},
+		{
+			Args:    []string{"1", "true", "sum"},
+			Want:    []string{"1", "true"},
+			WantErr: rpn.ErrExpectedANumber,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
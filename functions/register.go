@@ -32,12 +32,23 @@ func RegisterAll(r *rpn.RPN) {
 	r.Register("neg", negate, rpn.CatCore, negateHelp)
 	r.Register("round", round, rpn.CatCore, roundHelp)
 	r.Register("true", trueFn, rpn.CatCore, trueHelp)
+	r.Register("count", count, rpn.CatData, countHelp)
 	r.Register("del", del, rpn.CatData, delHelp)
 	r.Register("fields", fields, rpn.CatData, fieldsHelp)
 	r.Register("filter", filter, rpn.CatData, filterHelp)
+	r.Register("filterm", filterm, rpn.CatData, filtermHelp)
+	r.Register("filtermn", filtermn, rpn.CatData, filtermnHelp)
+	r.Register("filtern", filtern, rpn.CatData, filternHelp)
+	r.Register("fmt", fmtFn, rpn.CatData, fmtHelp)
 	r.Register("keep", keep, rpn.CatData, keepHelp)
+	r.Register("prod", prod, rpn.CatData, prodHelp)
+	r.Register("range", rangeFn, rpn.CatData, rangeHelp)
+	r.Register("reduce", reduce, rpn.CatData, reduceHelp)
 	r.Register("reverse", reverse, rpn.CatData, reverseHelp)
 	r.Register("sort", sortFn, rpn.CatData, sortHelp)
+	r.Register("sum", sum, rpn.CatData, sumHelp)
+	r.Register("unique", unique, rpn.CatData, uniqueHelp)
+	r.Register("zip", zip, rpn.CatData, zipHelp)
 
 	r.Register("**", power, rpn.CatEng, powerHelp)
 	r.Register("acos", acos, rpn.CatEng, acosHelp)
@@ -64,6 +75,12 @@ func RegisterAll(r *rpn.RPN) {
 	r.Register("printx", printx, rpn.CatIO, printxHelp)
 
 	r.Register("@", exec, rpn.CatProg, execHelp)
+	r.Register("assert", assert, rpn.CatProg, assertHelp)
+	r.Register("assert.eq", assertEq, rpn.CatProg, assertEqHelp)
+	r.Register("assert.err", assertErr, rpn.CatProg, assertErrHelp)
+	r.Register("assert.stack", assertStack, rpn.CatProg, assertStackHelp)
+	r.Register("break", breakFn, rpn.CatProg, breakHelp)
+	r.Register("continue", continueFn, rpn.CatProg, continueHelp)
 	r.Register("delay", delay, rpn.CatProg, delayHelp)
 	r.Register("error", errorFn, rpn.CatProg, errorHelp)
 	r.Register("for", forFn, rpn.CatProg, forHelp)
@@ -71,12 +88,46 @@ func RegisterAll(r *rpn.RPN) {
 	r.Register("ifelse", ifelse, rpn.CatProg, ifelseHelp)
 	r.Register("noop", noop, rpn.CatProg, noopHelp)
 	r.Register("time", timeFn, rpn.CatProg, timeHelp)
+	r.Register("times", times, rpn.CatProg, timesHelp)
 	r.Register("try", try, rpn.CatProg, tryHelp)
+	r.Register("while", while, rpn.CatProg, whileHelp)
 
+	r.Register("-rot", rotBack, rpn.CatStack, rotBackHelp)
+	r.Register("2dup", dup2, rpn.CatStack, dup2Help)
 	r.Register("d", dropAll, rpn.CatStack, dropAllHelp)
+	r.Register("depth", depth, rpn.CatStack, depthHelp)
+	r.Register("drop", drop, rpn.CatStack, dropHelp)
+	r.Register("dup", dup, rpn.CatStack, dupHelp)
+	r.Register("dupn", dupn, rpn.CatStack, dupnHelp)
+	r.Register("nip", nip, rpn.CatStack, nipHelp)
+	r.Register("over", over, rpn.CatStack, overHelp)
+	r.Register("pick", pick, rpn.CatStack, pickHelp)
+	r.Register("roll", roll, rpn.CatStack, rollHelp)
+	r.Register("rot", rot, rpn.CatStack, rotHelp)
+	r.Register("swap", swap, rpn.CatStack, swapHelp)
+	r.Register("tuck", tuck, rpn.CatStack, tuckHelp)
 
 	r.Register("heapstats", heapstats, rpn.CatStatus, heapstatsHelp)
 
+	r.Register("chr", chr, rpn.CatString, chrHelp)
+	r.Register("ord", ord, rpn.CatString, ordHelp)
+	r.Register("str.endswith", strEndsWith, rpn.CatString, strEndsWithHelp)
+	r.Register("str.find", strFind, rpn.CatString, strFindHelp)
+	r.Register("str.join", strJoin, rpn.CatString, strJoinHelp)
+	r.Register("str.joinv", strJoinv, rpn.CatString, strJoinvHelp)
+	r.Register("str.len", strLen, rpn.CatString, strLenHelp)
+	r.Register("str.lower", strLower, rpn.CatString, strLowerHelp)
+	r.Register("str.num", strNum, rpn.CatString, strNumHelp)
+	r.Register("str.pad", strPad, rpn.CatString, strPadHelp)
+	r.Register("str.repeat", strRepeat, rpn.CatString, strRepeatHelp)
+	r.Register("str.replace", strReplace, rpn.CatString, strReplaceHelp)
+	r.Register("str.reverse", strReverse, rpn.CatString, strReverseHelp)
+	r.Register("str.split", strSplit, rpn.CatString, strSplitHelp)
+	r.Register("str.startswith", strStartsWith, rpn.CatString, strStartsWithHelp)
+	r.Register("str.sub", strSub, rpn.CatString, strSubHelp)
+	r.Register("str.trim", strTrim, rpn.CatString, strTrimHelp)
+	r.Register("str.upper", strUpper, rpn.CatString, strUpperHelp)
+
 	r.Register("bin", bin, rpn.CatType, binHelp)
 	r.Register("float", floatFn, rpn.CatType, floatHelp)
 	r.Register("hex", hex, rpn.CatType, hexHelp)
@@ -87,4 +138,7 @@ func RegisterAll(r *rpn.RPN) {
 	r.Register("polar", polar, rpn.CatType, polarHelp)
 	r.Register("real", realFn, rpn.CatType, realHelp)
 	r.Register("str", str, rpn.CatType, strHelp)
+
+	registerConstants(r)
+	registerSignatures(r)
 }
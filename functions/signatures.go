@@ -0,0 +1,137 @@
+package functions
+
+import "mattwach/rpngo/rpn"
+
+// signatures declares what each command pops and pushes, see
+// rpn.Signature.  Commands that take optional arguments, such as range
+// and sum, are left out.
+var signatures = []struct {
+	name string
+	sig  string
+}{
+	{"!=", "a b -- r:bool"},
+	{"<", "a b -- r:bool"},
+	{"<=", "a b -- r:bool"},
+	{"=", "a b -- r:bool"},
+	{">", "a b -- r:bool"},
+	{">=", "a b -- r:bool"},
+
+	{"&", "a b -- c"},
+	{"|", "a b -- c"},
+	{"^", "a b -- c"},
+	{"<<", "a n -- c"},
+	{">>", "a n -- c"},
+
+	{"-", "a:num b:num -- c:num"},
+	{"*", "a:num b:num -- c:num"},
+	{"/", "a:num b:num -- c:num"},
+	{"+", "a b -- c"},
+	{"%", "a:int b:int -- c:int"},
+	{"false", " -- b:bool"},
+	{"frac", "x:num -- y:num"},
+	{"min", "a b -- c"},
+	{"max", "a b -- c"},
+	{"neg", "x -- y"},
+	{"round", "x:num places:int -- y:num"},
+	{"true", " -- b:bool"},
+	{"count", "fn -- n:int"},
+	{"del", "... n:int -- "},
+	{"fields", "s:str -- ..."},
+	{"fmt", "... template:str -- s:str"},
+	{"keep", "... n:int -- ..."},
+
+	{"**", "a:num b:num -- c:num"},
+	{"acos", "x:num -- y:num"},
+	{"asin", "x:num -- y:num"},
+	{"atan", "x:num -- y:num"},
+	{"abs", "x:num -- y:num"},
+	{"cos", "x:num -- y:num"},
+	{"log", "x:num -- y:num"},
+	{"log10", "x:num -- y:num"},
+	{"rand", " -- x:num"},
+	{"sin", "x:num -- y:num"},
+	{"sq", "x:num -- y:num"},
+	{"sqrt", "x:num -- y:num"},
+	{"tan", "x:num -- y:num"},
+
+	{"hexdump", "x -- "},
+	{"input", " -- s:str"},
+	{"print", "x -- x"},
+	{"printall", " -- "},
+	{"println", "x -- x"},
+	{"printlnx", "x -- "},
+	{"prints", "x -- x"},
+	{"printsx", "x -- "},
+	{"printx", "x -- "},
+
+	{"@", "... macro:str -- ..."},
+	{"assert.eq", "got want -- "},
+	{"assert.err", "macro:str -- "},
+	{"assert.stack", "want:str -- "},
+	{"break", " -- "},
+	{"continue", " -- "},
+	{"delay", "secs:num -- "},
+	{"error", "msg -- "},
+	{"for", "... body:str -- ..."},
+	{"if", "... cond:bool action -- ..."},
+	{"ifelse", "... cond:bool then else -- ..."},
+	{"noop", " -- "},
+	{"time", " -- t:num"},
+	{"times", "... n:int name body -- ..."},
+	{"try", "... body handler -- ..."},
+	{"while", "... cond body -- ..."},
+
+	{"-rot", "a b c -- c a b"},
+	{"2dup", "a b -- a b a b"},
+	{"d", "... -- "},
+	{"depth", " -- n:int"},
+	{"drop", "a -- "},
+	{"dup", "a -- a a"},
+	{"dupn", "... n:int -- ..."},
+	{"nip", "a b -- b"},
+	{"over", "a b -- a b a"},
+	{"pick", "... n:int -- ..."},
+	{"roll", "... n:int -- ..."},
+	{"rot", "a b c -- b c a"},
+	{"swap", "a b -- b a"},
+	{"tuck", "a b -- b a b"},
+
+	{"heapstats", " -- ..."},
+
+	{"chr", "n:int -- s:str"},
+	{"ord", "s:str -- n:int"},
+	{"str.endswith", "s:str suffix:str -- b:bool"},
+	{"str.find", "s:str sub:str -- i:int"},
+	{"str.join", "... sep:str -- s:str"},
+	{"str.joinv", "sep:str name:str -- s:str"},
+	{"str.len", "s:str -- n:int"},
+	{"str.lower", "s:str -- t:str"},
+	{"str.num", "s:str base:int -- n:int"},
+	{"str.pad", "s:str width:int fill:str -- t:str"},
+	{"str.repeat", "s:str n:int -- t:str"},
+	{"str.replace", "s:str old:str new:str -- t:str"},
+	{"str.reverse", "s:str -- t:str"},
+	{"str.split", "s:str sep:str -- ..."},
+	{"str.startswith", "s:str prefix:str -- b:bool"},
+	{"str.sub", "s:str start:int end:int -- t:str"},
+	{"str.trim", "s:str -- t:str"},
+	{"str.upper", "s:str -- t:str"},
+
+	{"bin", "x -- n:int"},
+	{"float", "x -- y:num"},
+	{"hex", "x -- n:int"},
+	{"imag", "x:num -- y:num"},
+	{"int", "x -- n:int"},
+	{"oct", "x -- n:int"},
+	{"phase", "x:num -- y:num"},
+	{"polar", "x -- y:num"},
+	{"real", "x:num -- y:num"},
+	{"str", "x -- s:str"},
+}
+
+func registerSignatures(r *rpn.RPN) {
+	for _, s := range signatures {
+		// errors are caught by TestSignatures
+		_ = r.SetSignature(s.name, s.sig)
+	}
+}
@@ -0,0 +1,27 @@
+package functions
+
+import (
+	"mattwach/rpngo/rpn"
+	"testing"
+)
+
+func TestSignatures(t *testing.T) {
+	var r rpn.RPN
+	r.Init(256)
+	RegisterAll(&r)
+	for _, s := range signatures {
+		if err := r.SetSignature(s.name, s.sig); err != nil {
+			t.Errorf("SetSignature(%q, %q): %v", s.name, s.sig, err)
+		}
+	}
+}
+
+func TestSignatureLint(t *testing.T) {
+	var r rpn.RPN
+	r.Init(256)
+	RegisterAll(&r)
+	res := r.Lint("(x) $x sq $x * 2 swap -")
+	if !res.EffectKnown || (res.Pops != 1) || (res.Pushes != 1) {
+		t.Errorf("Lint=%+v, want pops 1, pushes 1", res)
+	}
+}
@@ -10,3 +10,190 @@ func dropAll(r *rpn.RPN) error {
 	r.Clear()
 	return nil
 }
+
+// The following are named stack words for Forth and HP users.  In the
+// stack effect comments, the head of the stack is on the right.
+
+const dupHelp = "Duplicates the head of the stack ( a -- a a ). Same as $0\n" +
+	"See Also: 2dup, dupn, over"
+
+func dup(r *rpn.RPN) error {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	return r.PushFrame(f)
+}
+
+const dropHelp = "Removes the head of the stack ( a -- ). Same as 0/\n" +
+	"See Also: nip, d"
+
+func drop(r *rpn.RPN) error {
+	_, err := r.DeleteFrame(0)
+	return err
+}
+
+const swapHelp = "Swaps the top two values ( a b -- b a ). Same as 1>\n" +
+	"See Also: rot, roll"
+
+func swap(r *rpn.RPN) error {
+	f, err := r.DeleteFrame(1)
+	if err != nil {
+		return err
+	}
+	return r.PushFrame(f)
+}
+
+const overHelp = "Copies the second value to the head ( a b -- a b a ). Same as $1\n" +
+	"See Also: dup, pick, tuck"
+
+func over(r *rpn.RPN) error {
+	f, err := r.PeekFrame(1)
+	if err != nil {
+		return err
+	}
+	return r.PushFrame(f)
+}
+
+const rotHelp = "Moves the third value to the head ( a b c -- b c a ). Same as 2>\n" +
+	"See Also: -rot, roll"
+
+func rot(r *rpn.RPN) error {
+	f, err := r.DeleteFrame(2)
+	if err != nil {
+		return err
+	}
+	return r.PushFrame(f)
+}
+
+const rotBackHelp = "Moves the head back to the third value ( a b c -- c a b ). Same as 2<\n" +
+	"See Also: rot, roll"
+
+func rotBack(r *rpn.RPN) error {
+	if len(r.Frames) < 3 {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	f, err := r.DeleteFrame(0)
+	if err != nil {
+		return err
+	}
+	return r.InsertFrame(f, 2)
+}
+
+const nipHelp = "Removes the second value ( a b -- b ). Same as 1/\n" +
+	"See Also: drop, tuck"
+
+func nip(r *rpn.RPN) error {
+	_, err := r.DeleteFrame(1)
+	return err
+}
+
+const tuckHelp = "Copies the head below the second value ( a b -- b a b )\n" +
+	"See Also: over, nip"
+
+func tuck(r *rpn.RPN) error {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	if len(r.Frames) < 2 {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	return r.InsertFrame(f, 2)
+}
+
+const pickHelp = "Pops n and copies the nth value (counting from 0 at the head) " +
+	"to the head ( xn ... x0 n -- xn ... x0 xn ). 0 pick is dup and 1 pick " +
+	"is over. Same as $n\n" +
+	"Example: 1 2 3 2 pick # 1 2 3 1\n" +
+	"See Also: roll, over"
+
+func pick(r *rpn.RPN) error {
+	n, err := popDepthArg(r, 1)
+	if err != nil {
+		return err
+	}
+	f, err := r.PeekFrame(n)
+	if err != nil {
+		return err
+	}
+	return r.PushFrame(f)
+}
+
+const rollHelp = "Pops n and moves the nth value (counting from 0 at the head) " +
+	"to the head ( xn ... x0 n -- ... x0 xn ). 1 roll is swap and 2 roll " +
+	"is rot. Same as n>\n" +
+	"Example: 1 2 3 4 3 roll # 2 3 4 1\n" +
+	"See Also: pick, rot"
+
+func roll(r *rpn.RPN) error {
+	n, err := popDepthArg(r, 1)
+	if err != nil {
+		return err
+	}
+	f, err := r.DeleteFrame(n)
+	if err != nil {
+		return err
+	}
+	return r.PushFrame(f)
+}
+
+const depthHelp = "Pushes the number of values on the stack ( -- n ). Same as s.size"
+
+func depth(r *rpn.RPN) error {
+	return r.PushFrame(rpn.IntFrame(int64(len(r.Frames)), rpn.INTEGER_FRAME))
+}
+
+const dup2Help = "Duplicates the top two values ( a b -- a b a b )\n" +
+	"See Also: dup, dupn"
+
+func dup2(r *rpn.RPN) error {
+	return dupFrames(r, 2)
+}
+
+const dupnHelp = "Pops n and duplicates the top n values. 1 dupn is dup and " +
+	"2 dupn is 2dup.\n" +
+	"Example: 1 2 3 2 dupn # 1 2 3 2 3\n" +
+	"See Also: dup, 2dup"
+
+func dupn(r *rpn.RPN) error {
+	n, err := popDepthArg(r, 0)
+	if err != nil {
+		return err
+	}
+	return dupFrames(r, n)
+}
+
+// popDepthArg pops a count n that refers to the values below it.  There
+// must be at least n+extra values below it.  The stack is unchanged on
+// error.
+func popDepthArg(r *rpn.RPN, extra int) (int, error) {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return 0, err
+	}
+	n, err := f.Int()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, rpn.ErrIllegalValue
+	}
+	if int(n)+extra > len(r.Frames)-1 {
+		return 0, rpn.ErrNotEnoughStackFrames
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	return int(n), nil
+}
+
+// dupFrames pushes copies of the top n values
+func dupFrames(r *rpn.RPN, n int) error {
+	if n > len(r.Frames) {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	if len(r.Frames)+n > r.MaxStackDepth() {
+		return rpn.ErrStackFull
+	}
+	r.Frames = append(r.Frames, r.Frames[len(r.Frames)-n:]...)
+	return nil
+}
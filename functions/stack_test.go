@@ -16,3 +16,125 @@ func TestDropAll(t *testing.T) {
 	}
 	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
 }
+
+func TestStackWords(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"1", "2", "dup"},
+			Want: []string{"1", "2", "2"},
+		},
+		{
+			Args:    []string{"dup"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args: []string{"1", "2", "drop"},
+			Want: []string{"1"},
+		},
+		{
+			Args:    []string{"drop"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args: []string{"1", "2", "3", "swap"},
+			Want: []string{"1", "3", "2"},
+		},
+		{
+			Args:    []string{"1", "swap"},
+			Want:    []string{"1"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args: []string{"1", "2", "over"},
+			Want: []string{"1", "2", "1"},
+		},
+		{
+			Args: []string{"1", "2", "3", "rot"},
+			Want: []string{"2", "3", "1"},
+		},
+		{
+			Args: []string{"1", "2", "3", "-rot"},
+			Want: []string{"3", "1", "2"},
+		},
+		{
+			Args:    []string{"1", "2", "-rot"},
+			Want:    []string{"1", "2"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args: []string{"1", "2", "3", "rot", "-rot"},
+			Want: []string{"1", "2", "3"},
+		},
+		{
+			Args: []string{"1", "2", "nip"},
+			Want: []string{"2"},
+		},
+		{
+			Args: []string{"1", "2", "tuck"},
+			Want: []string{"2", "1", "2"},
+		},
+		{
+			Args:    []string{"1", "tuck"},
+			Want:    []string{"1"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args: []string{"1", "2", "3", "2", "pick"},
+			Want: []string{"1", "2", "3", "1"},
+		},
+		{
+			Args: []string{"1", "2", "0", "pick"},
+			Want: []string{"1", "2", "2"},
+		},
+		{
+			Args:    []string{"1", "2", "2", "pick"},
+			Want:    []string{"1", "2", "2"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"1", "2", "-1", "pick"},
+			Want:    []string{"1", "2", "-1"},
+			WantErr: rpn.ErrIllegalValue,
+		},
+		{
+			Args: []string{"1", "2", "3", "4", "3", "roll"},
+			Want: []string{"2", "3", "4", "1"},
+		},
+		{
+			Args: []string{"1", "2", "1", "roll"},
+			Want: []string{"2", "1"},
+		},
+		{
+			Args:    []string{"1", "2", "'a'", "roll"},
+			Want:    []string{"1", "2", "'a'"},
+			WantErr: rpn.ErrExpectedANumber,
+		},
+		{
+			Args: []string{"depth", "1", "depth"},
+			Want: []string{"0d", "1", "2d"},
+		},
+		{
+			Args: []string{"1", "2", "2dup"},
+			Want: []string{"1", "2", "1", "2"},
+		},
+		{
+			Args:    []string{"1", "2dup"},
+			Want:    []string{"1"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args: []string{"1", "2", "3", "2", "dupn"},
+			Want: []string{"1", "2", "3", "2", "3"},
+		},
+		{
+			Args: []string{"1", "0", "dupn"},
+			Want: []string{"1"},
+		},
+		{
+			Args:    []string{"1", "2", "dupn"},
+			Want:    []string{"1", "2"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
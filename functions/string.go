@@ -3,6 +3,9 @@ package functions
 import (
 	"mattwach/rpngo/parse"
 	"mattwach/rpngo/rpn"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 const fieldsHelp = "Splits a string into fields and places all fields on the stack"
@@ -20,3 +23,415 @@ func fields(r *rpn.RPN) error {
 	}
 	return parse.Fields(f.String(false), fn)
 }
+
+// stringArg returns the string at stack position i, with 0 being the head
+func stringArg(r *rpn.RPN, i int) (string, rpn.FrameType, error) {
+	f, err := r.PeekFrame(i)
+	if err != nil {
+		return "", 0, err
+	}
+	if !f.IsString() {
+		return "", 0, rpn.ErrExpectedAString
+	}
+	return f.UnsafeString(), f.Type(), nil
+}
+
+// intArg returns the integer at stack position i, with 0 being the head
+func intArg(r *rpn.RPN, i int) (int, error) {
+	f, err := r.PeekFrame(i)
+	if err != nil {
+		return 0, err
+	}
+	v, err := f.Int()
+	return int(v), err
+}
+
+// replaceArgs pops n arguments and pushes f
+func replaceArgs(r *rpn.RPN, n int, f rpn.Frame) error {
+	r.Frames = r.Frames[:len(r.Frames)-n]
+	return r.PushFrame(f)
+}
+
+// stringFn replaces the string at the head of the stack with fn(s)
+func stringFn(r *rpn.RPN, fn func(string) string) error {
+	s, t, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	return replaceArgs(r, 1, rpn.StringFrame(fn(s), t))
+}
+
+const strLenHelp = "Replaces a string with its length in characters\n" +
+	"Example: 'héllo' str.len # 5\n" +
+	"See Also: str.sub"
+
+func strLen(r *rpn.RPN) error {
+	s, _, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	return replaceArgs(r, 1, rpn.IntFrame(int64(utf8.RuneCountInString(s)), rpn.INTEGER_FRAME))
+}
+
+const strSubHelp = "Pops a string, a start index and an end index and pushes " +
+	"the characters from start up to, but not including, end.  Indexes " +
+	"start at 0 and negative indexes count back from the end of the " +
+	"string.  Indexes past either end are clamped.\n" +
+	"Examples:\n" +
+	"  'hello' 1 3 str.sub # 'el'\n" +
+	"  'hello' -3 5 str.sub # 'llo'\n" +
+	"See Also: str.find, str.len"
+
+func strSub(r *rpn.RPN) error {
+	s, t, err := stringArg(r, 2)
+	if err != nil {
+		return err
+	}
+	start, err := intArg(r, 1)
+	if err != nil {
+		return err
+	}
+	end, err := intArg(r, 0)
+	if err != nil {
+		return err
+	}
+	runes := []rune(s)
+	start = clampIndex(start, len(runes))
+	end = clampIndex(end, len(runes))
+	if end < start {
+		end = start
+	}
+	return replaceArgs(r, 3, rpn.StringFrame(string(runes[start:end]), t))
+}
+
+// clampIndex converts a negative index to one counted from the start and
+// clamps it to [0, n]
+func clampIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
+
+const strFindHelp = "Pops a string and a string to find in it, then pushes " +
+	"the index of the first match or -1 if there is none.\n" +
+	"Example: 'hello' 'l' str.find # 2\n" +
+	"See Also: str.sub, str.startswith, str.endswith"
+
+func strFind(r *rpn.RPN) error {
+	s, _, err := stringArg(r, 1)
+	if err != nil {
+		return err
+	}
+	sub, _, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	i := strings.Index(s, sub)
+	if i > 0 {
+		i = utf8.RuneCountInString(s[:i])
+	}
+	return replaceArgs(r, 2, rpn.IntFrame(int64(i), rpn.INTEGER_FRAME))
+}
+
+const strReplaceHelp = "Pops a string, a string to find and its replacement, " +
+	"then pushes the string with every match replaced.\n" +
+	"Example: 'a-b-c' '-' '+' str.replace # 'a+b+c'\n" +
+	"See Also: str.find"
+
+func strReplace(r *rpn.RPN) error {
+	s, t, err := stringArg(r, 2)
+	if err != nil {
+		return err
+	}
+	old, _, err := stringArg(r, 1)
+	if err != nil {
+		return err
+	}
+	repl, _, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	return replaceArgs(r, 3, rpn.StringFrame(strings.ReplaceAll(s, old, repl), t))
+}
+
+const strUpperHelp = "Converts a string to upper case\n" +
+	"Example: 'Hello' str.upper # 'HELLO'\n" +
+	"See Also: str.lower"
+
+func strUpper(r *rpn.RPN) error {
+	return stringFn(r, strings.ToUpper)
+}
+
+const strLowerHelp = "Converts a string to lower case\n" +
+	"Example: 'Hello' str.lower # 'hello'\n" +
+	"See Also: str.upper"
+
+func strLower(r *rpn.RPN) error {
+	return stringFn(r, strings.ToLower)
+}
+
+const strTrimHelp = "Removes spaces, tabs and newlines from both ends of a string\n" +
+	"Example: '  hi \n' str.trim # 'hi'\n" +
+	"See Also: str.pad"
+
+func strTrim(r *rpn.RPN) error {
+	return stringFn(r, strings.TrimSpace)
+}
+
+const strReverseHelp = "Reverses the characters of a string\n" +
+	"Example: 'abc' str.reverse # 'cba'\n" +
+	"See Also: reverse"
+
+func strReverse(r *rpn.RPN) error {
+	return stringFn(r, func(s string) string {
+		runes := []rune(s)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	})
+}
+
+const strSplitHelp = "Pops a string and a delimiter, then pushes each part of " +
+	"the string between delimiters.  An empty delimiter splits the string " +
+	"into characters.\n" +
+	"Example: 'a,b,,c' ',' str.split # 'a' 'b' '' 'c'\n" +
+	"See Also: fields, str.join"
+
+func strSplit(r *rpn.RPN) error {
+	s, t, err := stringArg(r, 1)
+	if err != nil {
+		return err
+	}
+	sep, _, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(s, sep)
+	if len(r.Frames)-2+len(parts) > r.MaxStackDepth() {
+		return rpn.ErrStackFull
+	}
+	r.Frames = r.Frames[:len(r.Frames)-2]
+	for _, p := range parts {
+		if err := r.PushFrame(rpn.StringFrame(p, t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const strJoinHelp = "Pops a separator and replaces all values on the stack " +
+	"with a single string of the values separated by it.\n" +
+	"Example: 1 2 3 ', ' str.join # '1, 2, 3'\n" +
+	"See Also: str.joinv, str.split"
+
+func strJoin(r *rpn.RPN) error {
+	sep, _, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	s := joinFrames(r.Frames[:len(r.Frames)-1], sep)
+	r.Frames = r.Frames[:0]
+	return r.PushFrame(rpn.StringFrame(s, rpn.STRING_SINGLEQ_FRAME))
+}
+
+const strJoinvHelp = "Pops a separator and a variable name, then pushes a " +
+	"string of the variable's values separated by the separator.\n" +
+	"Example: 1 3 1 'x' range ', ' 'x' str.joinv # '1, 2, 3'\n" +
+	"See Also: str.join"
+
+func strJoinv(r *rpn.RPN) error {
+	sep, _, err := stringArg(r, 1)
+	if err != nil {
+		return err
+	}
+	name, _, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	values, err := r.GetVariableStack(name)
+	if err != nil {
+		return err
+	}
+	return replaceArgs(r, 2, rpn.StringFrame(joinFrames(values, sep), rpn.STRING_SINGLEQ_FRAME))
+}
+
+func joinFrames(frames []rpn.Frame, sep string) string {
+	var sb strings.Builder
+	for i := range frames {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(frames[i].String(false))
+	}
+	return sb.String()
+}
+
+const strRepeatHelp = "Pops a string and a count and pushes the string " +
+	"repeated count times.\n" +
+	"Example: 'ab' 3 str.repeat # 'ababab'\n" +
+	"See Also: str.pad"
+
+func strRepeat(r *rpn.RPN) error {
+	s, t, err := stringArg(r, 1)
+	if err != nil {
+		return err
+	}
+	n, err := intArg(r, 0)
+	if err != nil {
+		return err
+	}
+	if (n < 0) || ((n > 0) && (len(s) > maxStringLength/n)) {
+		return rpn.ErrIllegalValue
+	}
+	return replaceArgs(r, 2, rpn.StringFrame(strings.Repeat(s, n), t))
+}
+
+// maxStringLength keeps str.repeat and str.pad from using all of the memory
+const maxStringLength = 1 << 20
+
+const strPadHelp = "Pops a string, a width and a fill character, then pads " +
+	"the string with the fill character until it is width characters " +
+	"long.  A positive width pads on the left and a negative width pads on " +
+	"the right.  Strings that are already long enough are not changed.\n" +
+	"Examples:\n" +
+	"  '7' 3 '0' str.pad # '007'\n" +
+	"  'ab' -4 '.' str.pad # 'ab..'\n" +
+	"See Also: fmt, str.trim"
+
+func strPad(r *rpn.RPN) error {
+	s, t, err := stringArg(r, 2)
+	if err != nil {
+		return err
+	}
+	width, err := intArg(r, 1)
+	if err != nil {
+		return err
+	}
+	fill, _, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(fill) != 1 {
+		return rpn.ErrIllegalValue
+	}
+	left := width > 0
+	if !left {
+		width = -width
+	}
+	if width > maxStringLength {
+		return rpn.ErrIllegalValue
+	}
+	if n := width - utf8.RuneCountInString(s); n > 0 {
+		if left {
+			s = strings.Repeat(fill, n) + s
+		} else {
+			s += strings.Repeat(fill, n)
+		}
+	}
+	return replaceArgs(r, 3, rpn.StringFrame(s, t))
+}
+
+const strStartsWithHelp = "Pops a string and a prefix and pushes true if the " +
+	"string starts with the prefix\n" +
+	"Example: 'hello' 'he' str.startswith # true\n" +
+	"See Also: str.endswith, str.find"
+
+func strStartsWith(r *rpn.RPN) error {
+	return stringTest(r, strings.HasPrefix)
+}
+
+const strEndsWithHelp = "Pops a string and a suffix and pushes true if the " +
+	"string ends with the suffix\n" +
+	"Example: 'data.csv' '.csv' str.endswith # true\n" +
+	"See Also: str.startswith, str.find"
+
+func strEndsWith(r *rpn.RPN) error {
+	return stringTest(r, strings.HasSuffix)
+}
+
+func stringTest(r *rpn.RPN, fn func(s, t string) bool) error {
+	s, _, err := stringArg(r, 1)
+	if err != nil {
+		return err
+	}
+	t, _, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	return replaceArgs(r, 2, rpn.BoolFrame(fn(s, t)))
+}
+
+const chrHelp = "Replaces a Unicode code point with a string of that character\n" +
+	"Example: 65 chr # 'A'\n" +
+	"See Also: ord"
+
+func chr(r *rpn.RPN) error {
+	n, err := intArg(r, 0)
+	if err != nil {
+		return err
+	}
+	if (n < 0) || (n > utf8.MaxRune) || !utf8.ValidRune(rune(n)) {
+		return rpn.ErrIllegalValue
+	}
+	return replaceArgs(r, 1, rpn.StringFrame(string(rune(n)), rpn.STRING_SINGLEQ_FRAME))
+}
+
+const ordHelp = "Replaces a one character string with its Unicode code point\n" +
+	"Example: 'A' ord # 65\n" +
+	"See Also: chr"
+
+func ord(r *rpn.RPN) error {
+	s, _, err := stringArg(r, 0)
+	if err != nil {
+		return err
+	}
+	c, size := utf8.DecodeRuneInString(s)
+	if (size == 0) || (size != len(s)) {
+		return rpn.ErrIllegalValue
+	}
+	return replaceArgs(r, 1, rpn.IntFrame(int64(c), rpn.INTEGER_FRAME))
+}
+
+const strNumHelp = "Pops a string and a base from 2 to 36 and pushes the " +
+	"integer that the string holds in that base.  Bases 2, 8 and 16 push " +
+	"binary, octal and hexidecimal integers.\n" +
+	"Examples:\n" +
+	"  'ff' 16 str.num # ffx\n" +
+	"  '-42' 10 str.num # -42d\n" +
+	"See Also: int, str"
+
+func strNum(r *rpn.RPN) error {
+	s, _, err := stringArg(r, 1)
+	if err != nil {
+		return err
+	}
+	base, err := intArg(r, 0)
+	if err != nil {
+		return err
+	}
+	if (base < 2) || (base > 36) {
+		return rpn.ErrIllegalValue
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(s), base, 64)
+	if err != nil {
+		return rpn.ErrIllegalValue
+	}
+	var t rpn.FrameType = rpn.INTEGER_FRAME
+	switch base {
+	case 2:
+		t = rpn.BINARY_FRAME
+	case 8:
+		t = rpn.OCTAL_FRAME
+	case 16:
+		t = rpn.HEXIDECIMAL_FRAME
+	}
+	return replaceArgs(r, 2, rpn.IntFrame(v, t))
+}
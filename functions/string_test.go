@@ -32,3 +32,68 @@ func TestFields(t *testing.T) {
 	}
 	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
 }
+
+func TestStringCommands(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{Args: []string{"'héllo'", "str.len"}, Want: []string{"5d"}},
+		{Args: []string{"''", "str.len"}, Want: []string{"0d"}},
+		{Args: []string{"5", "str.len"}, WantErr: rpn.ErrExpectedAString, Want: []string{"5"}},
+
+		{Args: []string{"'hello'", "1", "3", "str.sub"}, Want: []string{"'el'"}},
+		{Args: []string{"'hello'", "-3", "5", "str.sub"}, Want: []string{"'llo'"}},
+		{Args: []string{"'hello'", "-10", "100", "str.sub"}, Want: []string{"'hello'"}},
+		{Args: []string{"'hello'", "3", "1", "str.sub"}, Want: []string{"''"}},
+		{Args: []string{"'héllo'", "1", "2", "str.sub"}, Want: []string{"'é'"}},
+		{Args: []string{"\"hello\"", "0", "1", "str.sub"}, Want: []string{"\"h\""}},
+		{Args: []string{"'hello'", "'a'", "1", "str.sub"}, WantErr: rpn.ErrExpectedANumber, Want: []string{"'hello'", "'a'", "1"}},
+		{Args: []string{"1", "3", "str.sub"}, WantErr: rpn.ErrNotEnoughStackFrames, Want: []string{"1", "3"}},
+
+		{Args: []string{"'hello'", "'l'", "str.find"}, Want: []string{"2d"}},
+		{Args: []string{"'héllo'", "'l'", "str.find"}, Want: []string{"2d"}},
+		{Args: []string{"'hello'", "'z'", "str.find"}, Want: []string{"-1d"}},
+
+		{Args: []string{"'a-b-c'", "'-'", "'+'", "str.replace"}, Want: []string{"'a+b+c'"}},
+		{Args: []string{"'Hello'", "str.upper"}, Want: []string{"'HELLO'"}},
+		{Args: []string{"'Hello'", "str.lower"}, Want: []string{"'hello'"}},
+		{Args: []string{"'  hi  '", "str.trim"}, Want: []string{"'hi'"}},
+		{Args: []string{"'abé'", "str.reverse"}, Want: []string{"'éba'"}},
+
+		{Args: []string{"'a,b,,c'", "','", "str.split"}, Want: []string{"'a'", "'b'", "''", "'c'"}},
+		{Args: []string{"'abc'", "''", "str.split"}, Want: []string{"'a'", "'b'", "'c'"}},
+		{Args: []string{"1", "'x'", "true", "', '", "str.join"}, Want: []string{"'1, x, true'"}},
+		{Args: []string{"''", "str.join"}, Want: []string{"''"}},
+		{Args: []string{"1", "x<", "2", "x<", "5", "'-'", "'x'", "str.joinv"}, Want: []string{"5", "'1-2'"}},
+		{Args: []string{"'-'", "'nope'", "str.joinv"}, WantErr: rpn.ErrNotFound, Want: []string{"'-'", "'nope'"}},
+
+		{Args: []string{"'ab'", "3", "str.repeat"}, Want: []string{"'ababab'"}},
+		{Args: []string{"'ab'", "0", "str.repeat"}, Want: []string{"''"}},
+		{Args: []string{"'ab'", "-1", "str.repeat"}, WantErr: rpn.ErrIllegalValue, Want: []string{"'ab'", "-1"}},
+		{Args: []string{"'ab'", "1e9", "str.repeat"}, WantErr: rpn.ErrIllegalValue, Want: []string{"'ab'", "1000000000"}},
+
+		{Args: []string{"'7'", "3", "'0'", "str.pad"}, Want: []string{"'007'"}},
+		{Args: []string{"'ab'", "-4", "'.'", "str.pad"}, Want: []string{"'ab..'"}},
+		{Args: []string{"'abcd'", "2", "' '", "str.pad"}, Want: []string{"'abcd'"}},
+		{Args: []string{"'a'", "3", "'xy'", "str.pad"}, WantErr: rpn.ErrIllegalValue, Want: []string{"'a'", "3", "'xy'"}},
+
+		{Args: []string{"'hello'", "'he'", "str.startswith"}, Want: []string{"true"}},
+		{Args: []string{"'hello'", "'lo'", "str.startswith"}, Want: []string{"false"}},
+		{Args: []string{"'data.csv'", "'.csv'", "str.endswith"}, Want: []string{"true"}},
+
+		{Args: []string{"65", "chr"}, Want: []string{"'A'"}},
+		{Args: []string{"233", "chr"}, Want: []string{"'é'"}},
+		{Args: []string{"-1", "chr"}, WantErr: rpn.ErrIllegalValue, Want: []string{"-1"}},
+		{Args: []string{"55296", "chr"}, WantErr: rpn.ErrIllegalValue, Want: []string{"55296"}},
+		{Args: []string{"'A'", "ord"}, Want: []string{"65d"}},
+		{Args: []string{"'é'", "ord"}, Want: []string{"233d"}},
+		{Args: []string{"''", "ord"}, WantErr: rpn.ErrIllegalValue, Want: []string{"''"}},
+		{Args: []string{"'AB'", "ord"}, WantErr: rpn.ErrIllegalValue, Want: []string{"'AB'"}},
+
+		{Args: []string{"'ff'", "16", "str.num"}, Want: []string{"ffx"}},
+		{Args: []string{"'-42'", "10", "str.num"}, Want: []string{"-42d"}},
+		{Args: []string{"'101'", "2", "str.num"}, Want: []string{"101b"}},
+		{Args: []string{"'z'", "36", "str.num"}, Want: []string{"35d"}},
+		{Args: []string{"'12'", "1", "str.num"}, WantErr: rpn.ErrIllegalValue, Want: []string{"'12'", "1"}},
+		{Args: []string{"'12x'", "10", "str.num"}, WantErr: rpn.ErrIllegalValue, Want: []string{"'12x'", "10"}},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
@@ -0,0 +1,70 @@
+package functions
+
+import "mattwach/rpngo/rpn"
+
+const uniqueHelp = "Removes repeated values from the stack, keeping the " +
+	"first (deepest) copy of each.  Values are compared like =, so 1 and " +
+	"1.0 are the same.\n" +
+	"Example: 1 2 1 3 2 unique  # 1 2 3\n" +
+	"See Also: sort, count"
+
+func unique(r *rpn.RPN) error {
+	n := 0
+	for _, f := range r.Frames {
+		found := false
+		for _, kept := range r.Frames[:n] {
+			if kept.IsEqual(f) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.Frames[n] = f
+			n++
+		}
+	}
+	r.Frames = r.Frames[:n]
+	return nil
+}
+
+const countHelp = "Pops a macro and pushes the number of values on the " +
+	"stack that it returns true for.  The macro is called with each value " +
+	"pushed to the head of the stack and should replace it with a boolean.  " +
+	"The values are left on the stack.\n" +
+	"Example: 5 -2 7 0 {0 >} count  # 2\n" +
+	"See Also: filter, unique, s.size"
+
+func count(r *rpn.RPN) error {
+	fn, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	macro, err := r.CompileMacro(fn.String(false))
+	if err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	values := make([]rpn.Frame, len(r.Frames))
+	copy(values, r.Frames)
+	var n int64
+	for _, v := range values {
+		if err := r.PushFrame(v); err != nil {
+			return err
+		}
+		if err := r.ExecCompiled(macro); err != nil {
+			return err
+		}
+		cf, err := r.PopFrame()
+		if err != nil {
+			return err
+		}
+		match, err := cf.Bool()
+		if err != nil {
+			return err
+		}
+		if match {
+			n++
+		}
+	}
+	return r.PushFrame(rpn.IntFrame(n, rpn.INTEGER_FRAME))
+}
@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"mattwach/rpngo/rpn"
+	"testing"
+)
+
+func TestUnique(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"unique"},
+		},
+		{
+			Args: []string{"1", "2", "1", "3", "2", "unique"},
+			Want: []string{"1", "2", "3"},
+		},
+		{
+			Args: []string{"1d", "1", "'a'", "'a'", "true", "unique"},
+			Want: []string{"1d", "'a'", "true"},
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
+
+func TestCount(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"5", "-2", "7", "0", "{0 >}", "count"},
+			Want: []string{"5", "-2", "7", "0", "2d"},
+		},
+		{
+			Args: []string{"{0 >}", "count"},
+			Want: []string{"0d"},
+		},
+		{
+			Args:    []string{"count"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"1", "{2 +}", "count"},
+			Want:    []string{"1"},
+			WantErr: rpn.ErrExpectedABoolean,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
@@ -0,0 +1,40 @@
+package functions
+
+import "mattwach/rpngo/rpn"
+
+const zipHelp = "Pops two variable names and pushes the values of their " +
+	"stacks in pairs, oldest first.  If one variable has more values than " +
+	"the other, the extra values are ignored.\n" +
+	"Example: 1 3 1 'x' range 4 6 1 'y' range 'x' 'y' zip  # 1 4 2 5 3 6\n" +
+	"See Also: range, filterm"
+
+func zip(r *rpn.RPN) error {
+	if len(r.Frames) < 2 {
+		return rpn.ErrNotEnoughStackFrames
+	}
+	af := r.Frames[len(r.Frames)-2]
+	bf := r.Frames[len(r.Frames)-1]
+	if !af.IsString() || !bf.IsString() {
+		return rpn.ErrExpectedAString
+	}
+	a, err := r.GetVariableStack(af.UnsafeString())
+	if err != nil {
+		return err
+	}
+	b, err := r.GetVariableStack(bf.UnsafeString())
+	if err != nil {
+		return err
+	}
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	if len(r.Frames)-2+2*n > r.MaxStackDepth() {
+		return rpn.ErrStackFull
+	}
+	r.Frames = r.Frames[:len(r.Frames)-2]
+	for i := 0; i < n; i++ {
+		r.Frames = append(r.Frames, a[i], b[i])
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package functions
+
+import (
+	"mattwach/rpngo/rpn"
+	"testing"
+)
+
+func TestZip(t *testing.T) {
+	data := []rpn.UnitTestExecData{
+		{
+			Args: []string{"1", "3", "1", "'x'", "range", "4", "6", "1", "'y'", "range", "'x'", "'y'", "zip"},
+			Want: []string{"1", "4", "2", "5", "3", "6"},
+		},
+		{
+			Name: "different lengths",
+			Args: []string{"1", "3", "1", "'x'", "range", "4", "y<", "'x'", "'y'", "zip"},
+			Want: []string{"1", "4"},
+		},
+		{
+			Args:    []string{"1", "x=", "'x'", "'y'", "zip"},
+			Want:    []string{"'x'", "'y'"},
+			WantErr: rpn.ErrNotFound,
+		},
+		{
+			Args:    []string{"'x'", "zip"},
+			Want:    []string{"'x'"},
+			WantErr: rpn.ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"1", "'x'", "zip"},
+			Want:    []string{"1", "'x'"},
+			WantErr: rpn.ErrExpectedAString,
+		},
+	}
+	rpn.UnitTestExecAll(t, data, func(r *rpn.RPN) { RegisterAll(r) })
+}
@@ -44,4 +44,6 @@ const (
 	KEY_SAVE  // 290
 	KEY_QUIT  // 291
 	KEY_HELP  // 292
+	// not a key, see rpn.TimerDue
+	KEY_TIMER // 293
 )
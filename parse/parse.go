@@ -24,6 +24,14 @@ var (
 	ErrUnterminatedBrace       = errors.New("unterminatd brace")
 )
 
+// PassThroughError is returned by Fields as-is, without adding context.  It
+// is used for control flow, such as breaking out of a loop.
+type PassThroughError string
+
+func (e PassThroughError) Error() string {
+	return string(e)
+}
+
 type State uint8
 
 const (
@@ -180,6 +188,9 @@ func Fields(m string, fn func(string) error) error {
 			parse.comment(c)
 		}
 		if err != nil {
+			if _, ok := err.(PassThroughError); ok {
+				return err
+			}
 			elog.Heap("alloc: /parse/parse.go:163: buildContextString(m, starti, i),")
 			return fmt.Errorf(
 				"%s: %w",
@@ -200,6 +211,9 @@ func Fields(m string, fn func(string) error) error {
 	}
 
 	if err != nil {
+		if _, ok := err.(PassThroughError); ok {
+			return err
+		}
 		elog.Heap("alloc: /parse/parse.go:180: buildContextString(m, starti, len(m)),")
 		return fmt.Errorf(
 			"%s: %w",
@@ -209,9 +223,94 @@ func Fields(m string, fn func(string) error) error {
 	return nil
 }
 
+// FieldsAt is like Fields, but also passes fn the byte offsets in m where
+// each field starts and ends.  It does not use the static parser state, so
+// it is meant for tools such as lint rather than for executing macros.  If
+// there is an error, it is returned unchanged with the offset where the
+// failing field starts.
+func FieldsAt(m string, fn func(field string, start, end int) error) (int, error) {
+	var p parseData
+	var err error
+	starti := 0
+	for i, c := range m {
+		switch p.s {
+		case WHITESPACE:
+			p.whitespace(c)
+			if p.s != WHITESPACE {
+				starti = i
+			}
+		case TOKEN:
+			err = p.token(c, func(field string) error {
+				return fn(field, starti, i)
+			})
+		case STRING_SINGLE, STRING_DOUBLE, STRING_BRACES:
+			err = p.str(c, func(field string) error {
+				return fn(field, starti, i+1)
+			})
+		case COMMENT:
+			p.comment(c)
+		}
+		if err != nil {
+			return starti, err
+		}
+	}
+
+	switch p.s {
+	case TOKEN:
+		err = fn(string(p.t), starti, len(m))
+	case STRING_SINGLE:
+		err = ErrUnterminatedSingleQuote
+	case STRING_DOUBLE:
+		err = ErrUnterminatedDouble
+	case STRING_BRACES:
+		err = ErrUnterminatedBrace
+	}
+	if err != nil {
+		return starti, err
+	}
+	return len(m), nil
+}
+
+// LineColumn converts a byte offset in m to a line and column, both
+// starting at 1
+func LineColumn(m string, offset int) (int, int) {
+	line := 1
+	col := 1
+	for i, c := range m {
+		if i >= offset {
+			break
+		}
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
 // set a max context length so that the error location is not buried
 const maxContextLength = 80
 
+// ErrorContext returns the tokens with the token at idx marked, e.g.
+// "2 0 ->/<-", truncated to a readable length for error messages.
+func ErrorContext(tokens []string, idx int) string {
+	var m string
+	var starti, endi int
+	for i, t := range tokens {
+		if i > 0 {
+			m += " "
+		}
+		if i == idx {
+			starti = len(m)
+			endi = starti + len(t)
+		}
+		m += t
+	}
+	return buildContextString(m, starti, endi)
+}
+
 func buildContextString(m string, starti, endi int) string {
 	if len(m) > maxContextLength {
 		m, starti, endi = truncateString(m, starti, endi, maxContextLength)
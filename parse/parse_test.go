@@ -2,6 +2,7 @@ package parse
 
 import (
 	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -139,6 +140,19 @@ func TestFields(t *testing.T) {
 	}
 }
 
+func TestFieldsPassThroughError(t *testing.T) {
+	errPass := PassThroughError("pass")
+	errOther := errors.New("other")
+	err := Fields("a b", func(t string) error { return errPass })
+	if err != errPass {
+		t.Errorf("err = %v, want %v unwrapped", err, errPass)
+	}
+	err = Fields("a b", func(t string) error { return errOther })
+	if (err == errOther) || !errors.Is(err, errOther) {
+		t.Errorf("err = %v, want wrapped %v", err, errOther)
+	}
+}
+
 func TestTruncateString(t *testing.T) {
 	data := []struct {
 		name       string
@@ -239,3 +253,74 @@ func TestTruncateString(t *testing.T) {
 		})
 	}
 }
+
+func TestFieldsAt(t *testing.T) {
+	type field struct {
+		val        string
+		start, end int
+	}
+	data := []struct {
+		val       string
+		want      []field
+		wantStart int
+		wantErr   error
+	}{
+		{
+			val:       "",
+			wantStart: 0,
+		},
+		{
+			val:       " ab  'c d'\n{e {f}} # g\n",
+			want:      []field{{"ab", 1, 3}, {"'c d'", 5, 10}, {"{e {f}}", 11, 18}},
+			wantStart: 23,
+		},
+		{
+			val:       "1 {2 'x'",
+			want:      []field{{"1", 0, 1}},
+			wantStart: 2,
+			wantErr:   ErrUnterminatedBrace,
+		},
+		{
+			val:       "1\n  'abc",
+			want:      []field{{"1", 0, 1}},
+			wantStart: 4,
+			wantErr:   ErrUnterminatedSingleQuote,
+		},
+	}
+	for _, d := range data {
+		var got []field
+		start, err := FieldsAt(d.val, func(f string, start, end int) error {
+			got = append(got, field{f, start, end})
+			return nil
+		})
+		if !errors.Is(err, d.wantErr) {
+			t.Errorf("FieldsAt(%q) err=%v, want %v", d.val, err, d.wantErr)
+		}
+		if start != d.wantStart {
+			t.Errorf("FieldsAt(%q) start=%v, want %v", d.val, start, d.wantStart)
+		}
+		if !reflect.DeepEqual(got, d.want) {
+			t.Errorf("FieldsAt(%q)=%+v, want %+v", d.val, got, d.want)
+		}
+	}
+}
+
+func TestLineColumn(t *testing.T) {
+	m := "ab\ncd\n\nef"
+	data := []struct {
+		offset, line, col int
+	}{
+		{0, 1, 1},
+		{1, 1, 2},
+		{3, 2, 1},
+		{4, 2, 2},
+		{7, 4, 1},
+		{9, 4, 3},
+	}
+	for _, d := range data {
+		line, col := LineColumn(m, d.offset)
+		if line != d.line || col != d.col {
+			t.Errorf("LineColumn(%v)=%v:%v, want %v:%v", d.offset, line, col, d.line, d.col)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package rpn
+
+import (
+	"math"
+	"mattwach/rpngo/parse"
+	"strconv"
+	"strings"
+)
+
+// DebugAction is what the debugger should do after pausing
+type DebugAction uint8
+
+const (
+	// DebugStep pauses again before the next token, including tokens in
+	// called macros
+	DebugStep DebugAction = iota
+	// DebugStepOver pauses again before the next token at the same or a
+	// shallower call depth
+	DebugStepOver
+	// DebugContinue runs until the next breakpoint
+	DebugContinue
+	// DebugAbort stops the macro with ErrDebugAbort
+	DebugAbort
+)
+
+// DebugState describes where the debugger is paused
+type DebugState struct {
+	// Context is the macro with the next token marked, e.g. 1 2 ->+<-
+	Context string
+	// Token is the next token to execute
+	Token string
+	// Depth is the macro call depth, starting at 1
+	Depth int
+}
+
+// number of stack values DefaultDebugPrompt shows
+const debugStackFrames = 4
+
+type debugState struct {
+	stepping bool
+	// only pause at this call depth or shallower
+	maxDepth int
+}
+
+// debugPause is called before each token of a macro when stepping
+func (r *RPN) debugPause(m *Macro, idx int) error {
+	if r.callDepth > r.debug.maxDepth {
+		return nil
+	}
+	state := DebugState{
+		Context: parse.ErrorContext(m.args, idx),
+		Token:   m.args[idx],
+		Depth:   r.callDepth,
+	}
+	action, err := r.DebugPrompt(r, state)
+	if err != nil {
+		r.debug.stepping = false
+		return err
+	}
+	switch action {
+	case DebugStep:
+		r.debug.maxDepth = math.MaxInt
+	case DebugStepOver:
+		r.debug.maxDepth = r.callDepth
+	case DebugContinue:
+		r.debug.stepping = false
+	case DebugAbort:
+		r.debug.stepping = false
+		return ErrDebugAbort
+	}
+	return nil
+}
+
+// DefaultDebugPrompt prints the debugger state and reads a command
+// using Input.
+func DefaultDebugPrompt(r *RPN, state DebugState) (DebugAction, error) {
+	if r.Input == nil {
+		return DebugAbort, ErrNotSupported
+	}
+	r.Println("[" + strconv.Itoa(state.Depth) + "] " + state.Context)
+	count := len(r.Frames)
+	if count > debugStackFrames {
+		count = debugStackFrames
+	}
+	for i := count - 1; i >= 0; i-- {
+		f := r.Frames[len(r.Frames)-1-i]
+		r.Println("  " + strconv.Itoa(i) + ": " + f.String(true))
+	}
+	for {
+		r.Print("(s)tep (n)ext (c)ontinue (q)uit: ")
+		line, err := r.Input(r)
+		if err != nil {
+			return DebugAbort, err
+		}
+		switch strings.TrimSpace(line) {
+		case "", "s":
+			return DebugStep, nil
+		case "n":
+			return DebugStepOver, nil
+		case "c":
+			return DebugContinue, nil
+		case "q":
+			return DebugAbort, nil
+		}
+	}
+}
+
+const debugHelp = "Pops a macro and runs it in the debugger, pausing before " +
+	"each token.  When paused, the macro is shown with the next token " +
+	"marked, along with the call depth and the top of the stack.\n" +
+	"  s: step to the next token, including into called macros\n" +
+	"  n: step to the next token, skipping over called macros\n" +
+	"  c: continue until the next bp\n" +
+	"  q: abort the macro\n" +
+	"Example: $mymacro debug\n" +
+	"See Also: bp"
+
+func debug(r *RPN) error {
+	f, err := r.PopFrame()
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		r.PushFrame(f)
+		return ErrExpectedAString
+	}
+	r.debug.stepping = true
+	r.debug.maxDepth = math.MaxInt
+	defer func() { r.debug.stepping = false }()
+	return r.ExecMacro(f.UnsafeString())
+}
+
+const bpHelp = "A breakpoint.  Pauses in the debugger before the next token of " +
+	"the macro.  Does nothing when there is no interactive input.\n" +
+	"Example: {1 2 bp + 3 *} @\n" +
+	"See Also: debug"
+
+func bp(r *RPN) error {
+	if r.Input == nil {
+		return nil
+	}
+	r.debug.stepping = true
+	r.debug.maxDepth = r.callDepth
+	return nil
+}
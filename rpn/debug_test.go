@@ -0,0 +1,123 @@
+package rpn
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// scriptedDebugger replays actions and records where it paused
+type scriptedDebugger struct {
+	actions []DebugAction
+	paused  []string
+}
+
+func (d *scriptedDebugger) prompt(r *RPN, state DebugState) (DebugAction, error) {
+	d.paused = append(d.paused, strconv.Itoa(state.Depth)+":"+state.Token)
+	if len(d.actions) == 0 {
+		return DebugContinue, nil
+	}
+	a := d.actions[0]
+	d.actions = d.actions[1:]
+	return a, nil
+}
+
+func initDebugTest(actions ...DebugAction) (*RPN, *scriptedDebugger) {
+	var r RPN
+	r.Init(256)
+	d := &scriptedDebugger{actions: actions}
+	r.DebugPrompt = d.prompt
+	r.Input = func(*RPN) (string, error) { return "", nil }
+	return &r, d
+}
+
+func TestDebug(t *testing.T) {
+	data := []struct {
+		name       string
+		actions    []DebugAction
+		args       []string
+		wantPaused []string
+		wantErr    error
+	}{
+		{
+			name:       "step",
+			actions:    []DebugAction{DebugStep, DebugStep, DebugStep, DebugStep},
+			args:       []string{"{1 x=}", "f=", "{2 @f 3}", "debug"},
+			wantPaused: []string{"1:2", "1:@f", "2:1", "2:x=", "1:3"},
+		},
+		{
+			name:       "step over",
+			actions:    []DebugAction{DebugStep, DebugStepOver},
+			args:       []string{"{1 x=}", "f=", "{2 @f 3}", "debug"},
+			wantPaused: []string{"1:2", "1:@f", "1:3"},
+		},
+		{
+			name:       "continue",
+			actions:    []DebugAction{DebugContinue},
+			args:       []string{"{1 x=}", "f=", "{2 @f 3}", "debug"},
+			wantPaused: []string{"1:2"},
+		},
+		{
+			name:       "abort",
+			actions:    []DebugAction{DebugStep, DebugAbort},
+			args:       []string{"{2 @f 3}", "debug"},
+			wantPaused: []string{"1:2", "1:@f"},
+			wantErr:    ErrDebugAbort,
+		},
+		{
+			name:       "breakpoint",
+			actions:    []DebugAction{DebugContinue, DebugStep, DebugContinue},
+			args:       []string{"{1 bp 2 3 4}", "debug"},
+			wantPaused: []string{"1:1", "1:2", "1:3"},
+		},
+		{
+			name:       "breakpoint in called macro",
+			actions:    []DebugAction{DebugContinue, DebugStep, DebugStep},
+			args:       []string{"{1 bp}", "f=", "{@f 2}", "debug"},
+			wantPaused: []string{"1:@f", "1:2"},
+		},
+		{
+			name:       "breakpoint outside of debug",
+			actions:    []DebugAction{DebugStep, DebugContinue},
+			args:       []string{"{1 bp 2 3 4}", "f=", "@f"},
+			wantPaused: []string{"1:2", "1:3"},
+		},
+		{
+			name:    "not a string",
+			args:    []string{"1", "debug"},
+			wantErr: ErrExpectedAString,
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			r, dbg := initDebugTest(d.actions...)
+			err := r.ExecSlice(d.args)
+			if !errors.Is(err, d.wantErr) {
+				t.Fatalf("err=%v, want %v", err, d.wantErr)
+			}
+			if !reflect.DeepEqual(dbg.paused, d.wantPaused) {
+				t.Errorf("paused=%v, want %v", dbg.paused, d.wantPaused)
+			}
+			if r.debug.stepping {
+				t.Error("still stepping after debug returned")
+			}
+		})
+	}
+}
+
+func TestBreakpointWithoutInput(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	called := false
+	r.DebugPrompt = func(*RPN, DebugState) (DebugAction, error) {
+		called = true
+		return DebugContinue, nil
+	}
+	if err := r.ExecSlice([]string{"{bp 1}", "f=", "@f"}); err != nil {
+		t.Fatal(err)
+	}
+	if called {
+		t.Error("debugger should not be entered without input")
+	}
+}
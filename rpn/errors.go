@@ -1,10 +1,15 @@
 package rpn
 
-import "errors"
+import (
+	"errors"
+	"mattwach/rpngo/parse"
+)
 
 var (
+	ErrAssertion                 = errors.New("assertion failed")
 	ErrCanNotDeleteInputWindow   = errors.New("can not delete input window")
 	ErrCanNotDeleteRootWindow    = errors.New("can not delete root window")
+	ErrCallDepth                 = errors.New("maximum macro call depth exceeded")
 	ErrCanNotAddLabelToString    = errors.New("can not add label to string")
 	ErrChooseDegRadOGrad         = errors.New("choose 'deg', 'rad', or 'grad'")
 	ErrComplexNumberNotSupported = errors.New("complex number not suppported")
@@ -23,11 +28,25 @@ var (
 	ErrNotEnoughStackFrames      = errors.New("not enough stack frames")
 	ErrNotAWindowGroup           = errors.New("not a window group")
 	ErrNotSupported              = errors.New("not supported")
+	ErrSandboxed                 = errors.New("not allowed in the sandbox")
 	ErrNotFound                  = errors.New("not found")
+	ErrNothingToRedo             = errors.New("nothing to redo")
+	ErrNothingToUndo             = errors.New("nothing to undo")
 	ErrStackEmpty                = errors.New("stack empty")
 	ErrStackFull                 = errors.New("stack is full")
 	ErrSyntax                    = errors.New("syntax error (? for help)")
+	ErrTestsFailed               = errors.New("tests failed")
+	ErrTimeout                   = errors.New("timeout")
+	ErrTooManySteps              = errors.New("step limit exceeded")
 	ErrUnknownProperty           = errors.New("unknown property")
 	ErrInputWindowNotFound       = errors.New("input window not found")
 	ErrWindowAlreadyExists       = errors.New("window already exists")
 )
+
+// Control flow errors are passed up through ExecSlice and parse.Fields
+// without being wrapped so that loops (and try) can find them.
+var (
+	ErrBreak      error = parse.PassThroughError("break outside of a loop")
+	ErrContinue   error = parse.PassThroughError("continue outside of a loop")
+	ErrDebugAbort error = parse.PassThroughError("aborted by debugger")
+)
@@ -1,8 +1,6 @@
 package rpn
 
 import (
-	"fmt"
-	"mattwach/rpngo/elog"
 	"strconv"
 	"strings"
 )
@@ -12,8 +10,11 @@ func (rpn *RPN) Exec(arg string) error {
 	if rpn.Interrupt() {
 		return ErrInterrupted
 	}
+	if err := rpn.countStep(); err != nil {
+		return err
+	}
 	if fn := rpn.functions[arg]; fn != nil {
-		return fn(rpn)
+		return rpn.execProfiled(arg, fn)
 	}
 	if len(arg) > 1 {
 		switch arg[len(arg)-1] {
@@ -47,7 +48,7 @@ func (rpn *RPN) Exec(arg string) error {
 			rpn.PushFrame(f)
 			return nil
 		case '@':
-			return rpn.execVariableAsMacro(arg[1:])
+			return rpn.execVariableProfiled(arg, arg[1:])
 		case '`':
 			return rpn.addLabel(arg)
 		}
@@ -57,7 +58,7 @@ func (rpn *RPN) Exec(arg string) error {
 		switch last {
 		case '"':
 			if arg[0] == '"' {
-				return rpn.PushFrame(StringFrame(arg[1:len(arg)-1], STRING_DOUBLEQ_FRAME))
+				return rpn.pushDoubleQuoted(arg[1 : len(arg)-1])
 			}
 		case '\'':
 			if arg[0] == '\'' {
@@ -67,14 +68,12 @@ func (rpn *RPN) Exec(arg string) error {
 			if arg[0] == '{' {
 				return rpn.PushFrame(StringFrame(arg[1:len(arg)-1], STRING_BRACE_FRAME))
 			}
-		case 'd':
-			return rpn.parseAndPushInt(arg[:len(arg)-1], 10, INTEGER_FRAME)
-		case 'x':
-			return rpn.parseAndPushInt(arg[:len(arg)-1], 16, HEXIDECIMAL_FRAME)
-		case 'o':
-			return rpn.parseAndPushInt(arg[:len(arg)-1], 8, OCTAL_FRAME)
-		case 'b':
-			return rpn.parseAndPushInt(arg[:len(arg)-1], 2, BINARY_FRAME)
+		case 'd', 'x', 'o', 'b':
+			if strings.IndexByte(arg, '>') < 0 {
+				return rpn.parseAndPushSuffixInt(arg)
+			}
+			// otherwise it's a conversion that ends in one of these letters,
+			// e.g. kg>lb
 		}
 	}
 	if len(arg) > 0 && arg[len(arg)-1] == '?' {
@@ -89,8 +88,7 @@ func (rpn *RPN) Exec(arg string) error {
 func (rpn *RPN) ExecSlice(args []string) error {
 	for i, arg := range args {
 		if err := rpn.Exec(arg); err != nil {
-			elog.Heap("alloc: /rpn/exec.go:73: return fmt.Errorf('exec %s: %w', highlightArg(args, i), err)")
-			return fmt.Errorf("exec %s: %w", highlightArg(args, i), err) // object allocated on the heap: escapes at line 73
+			return rpn.wrapExecError(err, "", args, i, "exec "+highlightArg(args, i))
 		}
 	}
 	return nil
@@ -107,6 +105,20 @@ func highlightArg(args []string, idx int) string {
 	return strings.Join(parts, " ")
 }
 
+// parses an integer with a base suffix. e.g. 10d, ffx, 17o, 101b
+func (rpn *RPN) parseAndPushSuffixInt(arg string) error {
+	switch arg[len(arg)-1] {
+	case 'x':
+		return rpn.parseAndPushInt(arg[:len(arg)-1], 16, HEXIDECIMAL_FRAME)
+	case 'o':
+		return rpn.parseAndPushInt(arg[:len(arg)-1], 8, OCTAL_FRAME)
+	case 'b':
+		return rpn.parseAndPushInt(arg[:len(arg)-1], 2, BINARY_FRAME)
+	default:
+		return rpn.parseAndPushInt(arg[:len(arg)-1], 10, INTEGER_FRAME)
+	}
+}
+
 func (rpn *RPN) parseAndPushInt(arg string, base int, t FrameType) error {
 	v, err := strconv.ParseInt(arg, base, 64)
 	if err != nil {
@@ -16,6 +16,16 @@ func TestExecInterrupted(t *testing.T) {
 	}
 }
 
+func TestExecSliceLoopControl(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	r.Register("break", func(r *RPN) error { return ErrBreak }, CatProg, "")
+	err := r.ExecSlice([]string{"1", "break", "2"})
+	if err != ErrBreak {
+		t.Errorf("err got %v, want %v unwrapped", err, ErrBreak)
+	}
+}
+
 func TestExec(t *testing.T) {
 	data := []struct {
 		name       string
@@ -305,6 +315,24 @@ func TestExec(t *testing.T) {
 			frameCount: 1,
 			wantFrame:  Frame{ftype: COMPLEX_FRAME, str: "`km"},
 		},
+		{
+			name:       "conversion ending in b",
+			args:       []string{"0", "kg>lb"},
+			frameCount: 1,
+			wantFrame:  Frame{ftype: COMPLEX_FRAME, str: "`lb"},
+		},
+		{
+			name:       "conversion ending in d",
+			args:       []string{"0", "m>yd"},
+			frameCount: 1,
+			wantFrame:  Frame{ftype: COMPLEX_FRAME, str: "`yd"},
+		},
+		{
+			name:       "conversion with exponent",
+			args:       []string{"0", "kg*m^2/s^2>j"},
+			frameCount: 1,
+			wantFrame:  Frame{ftype: COMPLEX_FRAME, str: "`j"},
+		},
 		{
 			name: "help all",
 			args: []string{"?"},
@@ -42,6 +42,16 @@ func (rpn *RPN) initHelp() {
 
 		"conversions": rpn.conv.Help(),
 
+		"hooks": "These variables can be set to a macro that runs when\n" +
+			"something happens:\n" +
+			"  .onupdate   after every input line\n" +
+			"  .onerror    after an error is shown (see err.cause)\n" +
+			"  .onstartup  after the startup script\n" +
+			"  .onshutdown before exiting\n" +
+			"  .ontimer    every $.timer seconds while waiting for input\n" +
+			"Example: {time clock= 'v' w.update} .ontimer= 1 .timer=\n" +
+			"See Also: keymacros",
+
 		"keymacros": "The variables .f1 to .f12 can be set to a string.\n" +
 			"Pressing the corresponding function key will execute the string\n" +
 			"as a macro.",
@@ -51,6 +61,12 @@ func (rpn *RPN) initHelp() {
 			"Example:\n" +
 			"'. 3.14159 * *' cirarea=\n" +
 			"5 @cirarea\n" +
+			"A macro can start with a list of parameters.  These are popped\n" +
+			"from the stack into local variables that hide any variables with\n" +
+			"the same name until the macro returns.\n" +
+			"Example:\n" +
+			"{(x y) $x sq $y sq + sqrt} hyp=\n" +
+			"3 4 @hyp\n" +
 			"See Also: keymacros, variables",
 
 		"printing": "There are various printing functions that print values\n" +
@@ -73,7 +89,21 @@ func (rpn *RPN) initHelp() {
 			"   2> # Moves the third element in the stack to the head\n" +
 			"   2< # Moves the head element two backwards\n" +
 			"   $2 # Copies the third element in the stack to the head\n" +
-			"\n",
+			"\n" +
+			"Named stack words are also available for Forth and HP users:\n" +
+			"   dup  ( a -- a a )          same as $0\n" +
+			"   drop ( a -- )              same as 0/\n" +
+			"   swap ( a b -- b a )        same as 1>\n" +
+			"   over ( a b -- a b a )      same as $1\n" +
+			"   rot  ( a b c -- b c a )    same as 2>\n" +
+			"   -rot ( a b c -- c a b )    same as 2<\n" +
+			"   nip  ( a b -- b )          same as 1/\n" +
+			"   tuck ( a b -- b a b )\n" +
+			"   2dup ( a b -- a b a b )\n" +
+			"   n pick  copies the nth value to the head, same as $n\n" +
+			"   n roll  moves the nth value to the head, same as n>\n" +
+			"   n dupn  duplicates the top n values\n" +
+			"   depth   pushes the number of values, same as s.size\n",
 
 		"strings": "Enter a string value as 'example 1' or \"example 2\"",
 
@@ -118,6 +148,9 @@ func (r *RPN) printHelp(topic string) error {
 		return fmt.Errorf("use ? to list all: %w", ErrNotFound)
 	}
 	r.Print("\n")
+	if sig := r.signatures[topic]; sig != nil {
+		r.Println(sig.String())
+	}
 	r.Println(help)
 	return nil
 }
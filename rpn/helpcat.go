@@ -4,6 +4,7 @@ const (
 	CatConcepts  = "Base Concepts"
 	CatBitwise   = "Bitwise Arithmetic"
 	CatCompare   = "Comparison"
+	CatConst     = "Constants"
 	CatCore      = "Core Arithmetic"
 	CatData      = "Data Processing"
 	CatEng       = "Engineering / Scientific"
@@ -12,7 +13,9 @@ const (
 	CatProg      = "Programming"
 	CatStack     = "Stack Management"
 	CatStatus    = "Status"
+	CatString    = "Strings"
 	CatType      = "Value Types"
+	CatUser      = "User Commands"
 	CatVariables = "Variables"
 	CatWindow    = "Window Management"
 )
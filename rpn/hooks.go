@@ -0,0 +1,89 @@
+package rpn
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Hooks are macros in special variables that the main loop runs when
+// something happens
+const (
+	// HookUpdate runs after every input line
+	HookUpdate = ".onupdate"
+	// HookError runs after an error is shown
+	HookError = ".onerror"
+	// HookStartup runs after the startup script
+	HookStartup = ".onstartup"
+	// HookShutdown runs before the program exits
+	HookShutdown = ".onshutdown"
+	// HookTimer runs every $.timer seconds while waiting for input
+	HookTimer = ".ontimer"
+)
+
+// timerVar holds the number of seconds between HookTimer calls
+const timerVar = ".timer"
+
+type hookState struct {
+	// true while a hook runs so that hooks do not trigger each other
+	running bool
+	// when HookTimer runs next, zero if the timer is stopped
+	nextTimer time.Time
+}
+
+// RunHook runs the macro in the hook variable name, if it is set.
+func (r *RPN) RunHook(name string) error {
+	if r.hooks.running {
+		return nil
+	}
+	if _, err := r.GetVariable(name); err != nil {
+		return nil
+	}
+	r.hooks.running = true
+	defer func() { r.hooks.running = false }()
+	if err := r.Exec("@" + name); err != nil {
+		return fmt.Errorf("while executing $%s: %w", name, err)
+	}
+	return nil
+}
+
+// RunErrorHook runs HookError after err was shown.  err.cause, err.kind
+// and trace describe err while the hook runs.
+func (r *RPN) RunErrorHook(err error) error {
+	var ee *ExecError
+	if errors.As(err, &ee) {
+		r.lastError = ee
+	} else {
+		r.newExecError(err)
+	}
+	return r.RunHook(HookError)
+}
+
+// TimerDue returns true when HookTimer should run.  Main loops call it
+// while waiting for input.
+func (r *RPN) TimerDue() bool {
+	if _, err := r.GetVariable(HookTimer); err != nil {
+		r.hooks.nextTimer = time.Time{}
+		return false
+	}
+	secs, err := r.GetComplexVariable(timerVar)
+	if (err != nil) || (real(secs) <= 0) {
+		r.hooks.nextTimer = time.Time{}
+		return false
+	}
+	period := time.Duration(real(secs)*1000000) * time.Microsecond
+	now := time.Now()
+	if r.hooks.nextTimer.IsZero() {
+		r.hooks.nextTimer = now.Add(period)
+		return false
+	}
+	if now.Before(r.hooks.nextTimer) {
+		return false
+	}
+	r.hooks.nextTimer = r.hooks.nextTimer.Add(period)
+	if r.hooks.nextTimer.Before(now) {
+		// a slow hook should not make the calls bunch up
+		r.hooks.nextTimer = now.Add(period)
+	}
+	return true
+}
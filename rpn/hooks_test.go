@@ -0,0 +1,103 @@
+package rpn
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunHook(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	if err := r.RunHook(HookUpdate); err != nil {
+		t.Fatalf("unset hook: %v", err)
+	}
+	if err := r.ExecSlice([]string{"{5}", ".onupdate="}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RunHook(HookUpdate); err != nil {
+		t.Fatal(err)
+	}
+	// hooks do not run while another hook is running
+	r.hooks.running = true
+	if err := r.RunHook(HookUpdate); err != nil {
+		t.Fatal(err)
+	}
+	r.hooks.running = false
+	if got := framesToStrings(r.Frames); !reflect.DeepEqual(got, []string{"5"}) {
+		t.Errorf("stack=%v, want [5]", got)
+	}
+	if err := r.ExecSlice([]string{"{nope}", ".onupdate="}); err != nil {
+		t.Fatal(err)
+	}
+	err := r.RunHook(HookUpdate)
+	if !errors.Is(err, ErrSyntax) {
+		t.Fatalf("err=%v, want %v", err, ErrSyntax)
+	}
+	want := "while executing $.onupdate: "
+	if got := err.Error(); got[:len(want)] != want {
+		t.Errorf("err=%q, want prefix %q", got, want)
+	}
+	if r.hooks.running {
+		t.Error("running was not reset after an error")
+	}
+}
+
+func TestRunErrorHook(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	if err := r.ExecSlice([]string{"{err.cause}", ".onerror="}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RunErrorHook(ErrStackEmpty); err != nil {
+		t.Fatal(err)
+	}
+	err := r.Exec("nope")
+	if err := r.RunErrorHook(err); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"'stack empty'", "'syntax error (? for help)'"}
+	if got := framesToStrings(r.Frames); !reflect.DeepEqual(got, want) {
+		t.Errorf("stack=%v, want %v", got, want)
+	}
+}
+
+func TestTimerDue(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	if err := r.ExecSlice([]string{"0.02", ".timer="}); err != nil {
+		t.Fatal(err)
+	}
+	if r.TimerDue() {
+		t.Error("due without .ontimer")
+	}
+	if err := r.ExecSlice([]string{"{}", ".ontimer="}); err != nil {
+		t.Fatal(err)
+	}
+	if r.TimerDue() {
+		t.Error("due right after starting")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !r.TimerDue() {
+		t.Error("not due after the period")
+	}
+	if r.TimerDue() {
+		t.Error("due twice in a row")
+	}
+	if err := r.ExecSlice([]string{"0", ".timer="}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if r.TimerDue() {
+		t.Error("due with a zero period")
+	}
+}
+
+func framesToStrings(frames []Frame) []string {
+	var s []string
+	for _, f := range frames {
+		s = append(s, f.String(true))
+	}
+	return s
+}
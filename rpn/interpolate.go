@@ -0,0 +1,76 @@
+package rpn
+
+import "strings"
+
+// interpolate replaces each ${name} in s with the value of the variable
+// name.  Stack variables such as ${0} work too.  Text that is not a
+// variable reference, such as a lone $ or ${ without a closing }, is kept.
+func (r *RPN) interpolate(s string) (string, error) {
+	var sb strings.Builder
+	var err error
+	last := 0
+	interpolatedNames(s, func(offset int, name string) {
+		if err != nil {
+			return
+		}
+		var f Frame
+		if f, err = r.GetVariable(name); err != nil {
+			return
+		}
+		sb.WriteString(s[last:offset])
+		sb.WriteString(f.String(false))
+		last = offset + len(name) + 3
+	})
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(s[last:])
+	return sb.String(), nil
+}
+
+// interpolatedNames calls fn with the offset and name of each ${name} in s
+func interpolatedNames(s string, fn func(offset int, name string)) {
+	offset := 0
+	for {
+		start := strings.Index(s[offset:], "${")
+		if start < 0 {
+			return
+		}
+		start += offset
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return
+		}
+		end += start
+		name := s[start+2 : end]
+		if isInterpolatedName(name) {
+			fn(start, name)
+			offset = end + 1
+		} else {
+			offset = start + 2
+		}
+	}
+}
+
+func isInterpolatedName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for _, c := range name {
+		if !isAlphaNum(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// pushDoubleQuoted pushes a "string", replacing ${name} with variable values
+func (r *RPN) pushDoubleQuoted(s string) error {
+	if strings.Contains(s, "${") {
+		var err error
+		if s, err = r.interpolate(s); err != nil {
+			return err
+		}
+	}
+	return r.PushFrame(StringFrame(s, STRING_DOUBLEQ_FRAME))
+}
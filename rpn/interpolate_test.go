@@ -0,0 +1,62 @@
+package rpn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Args: []string{"5", "x=", "\"x=${x}\""},
+			Want: []string{"\"x=5\""},
+		},
+		{
+			Args: []string{"'a'", "x=", "'b'", "y=", "\"${x}${y} ${x}\""},
+			Want: []string{"\"ab a\""},
+		},
+		{
+			Args: []string{"5", "x=", "'x=${x}'"},
+			Want: []string{"'x=${x}'"},
+		},
+		{
+			Args: []string{"7", "\"head=${0}\""},
+			Want: []string{"7", "\"head=7\""},
+		},
+		{
+			Args: []string{"\"$ ${ ${} ${a b} ${x\""},
+			Want: []string{"\"$ ${ ${} ${a b} ${x\""},
+		},
+		{
+			Args:    []string{"\"${nope}\""},
+			WantErr: ErrNotFound,
+		},
+		{
+			Args: []string{"5", "x=", "{\"x=${x}\"}", "@"},
+			Want: []string{"\"x=5\""},
+		},
+	}
+	UnitTestExecAll(t, data, func(r *RPN) {
+		r.Register("@", func(r *RPN) error {
+			f, err := r.PopFrame()
+			if err != nil {
+				return err
+			}
+			return r.ExecMacro(f.String(false))
+		}, CatProg, "")
+	})
+}
+
+func TestInterpolateLint(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	res := r.Lint("5 x= \"${x} ${y} ${0}\"")
+	var issues []string
+	for _, issue := range res.Issues {
+		issues = append(issues, issue.String())
+	}
+	want := []string{"1:12: unknown variable: y"}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("issues=%q, want %q", issues, want)
+	}
+}
@@ -0,0 +1,118 @@
+package rpn
+
+import "time"
+
+// DefaultMaxCallDepth is the default limit on nested macro calls.  It is
+// low enough to avoid overflowing the stack on microcontrollers.
+const DefaultMaxCallDepth = 64
+
+// enterMacro is called before executing a macro
+func (r *RPN) enterMacro() error {
+	if (r.MaxCallDepth > 0) && (r.callDepth >= r.MaxCallDepth) {
+		return ErrCallDepth
+	}
+	r.callDepth++
+	return nil
+}
+
+// countStep is called before executing each token.  It enforces the step
+// budget (which is reset for each top-level token) and any timeout.
+func (r *RPN) countStep() error {
+	if r.MaxSteps > 0 {
+		if r.callDepth == 0 {
+			r.steps = 0
+		}
+		r.steps++
+		if r.steps > r.MaxSteps {
+			return ErrTooManySteps
+		}
+	}
+	if !r.deadline.IsZero() && time.Now().After(r.deadline) {
+		return ErrTimeout
+	}
+	return nil
+}
+
+const limitDepthHelp = "Pops the maximum number of nested macro calls.  " +
+	"Use 0 for no limit, which risks crashing on runaway recursion.\n" +
+	"Example: 200 limit.depth\n" +
+	"See Also: limit.steps, timeout"
+
+func limitDepth(r *RPN) error {
+	if r.Sandboxed() {
+		return ErrSandboxed
+	}
+	n, err := popLimit(r)
+	if err != nil {
+		return err
+	}
+	r.MaxCallDepth = n
+	return nil
+}
+
+const limitStepsHelp = "Pops the maximum number of tokens that any single " +
+	"command entered (or given on the command line) can execute, " +
+	"including the tokens of any macros it calls.  Use 0 for no limit " +
+	"(the default).\n" +
+	"Example: 1000000 limit.steps\n" +
+	"See Also: limit.depth, timeout"
+
+func limitSteps(r *RPN) error {
+	if r.Sandboxed() {
+		return ErrSandboxed
+	}
+	n, err := popLimit(r)
+	if err != nil {
+		return err
+	}
+	r.MaxSteps = n
+	return nil
+}
+
+func popLimit(r *RPN) (int, error) {
+	f, err := r.PopFrame()
+	if err != nil {
+		return 0, err
+	}
+	n, err := f.Int()
+	if err != nil {
+		r.PushFrame(f)
+		return 0, err
+	}
+	if n < 0 {
+		r.PushFrame(f)
+		return 0, ErrIllegalValue
+	}
+	return int(n), nil
+}
+
+const timeoutHelp = "Pops a number of seconds, then a macro.  Executes the " +
+	"macro, stopping it with an error if it runs for longer than the " +
+	"given time.\n" +
+	"Example: {@solve} 2.5 timeout\n" +
+	"See Also: limit.depth, limit.steps, try"
+
+func timeout(r *RPN) error {
+	if len(r.Frames) < 2 {
+		return ErrNotEnoughStackFrames
+	}
+	macrof := r.Frames[len(r.Frames)-2]
+	if !macrof.IsString() {
+		return ErrExpectedAString
+	}
+	secs, err := r.Frames[len(r.Frames)-1].Real()
+	if err != nil {
+		return err
+	}
+	if secs < 0 {
+		return ErrIllegalValue
+	}
+	r.Frames = r.Frames[:len(r.Frames)-2]
+	deadline := time.Now().Add(time.Duration(secs * float64(time.Second)))
+	prev := r.deadline
+	if prev.IsZero() || deadline.Before(prev) {
+		r.deadline = deadline
+	}
+	defer func() { r.deadline = prev }()
+	return r.ExecMacro(macrof.UnsafeString())
+}
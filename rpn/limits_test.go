@@ -0,0 +1,86 @@
+package rpn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCallDepthLimit(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Args:    []string{"{@f}", "f=", "@f"},
+			WantErr: ErrCallDepth,
+		},
+		{
+			Args: []string{"3", "limit.depth", "{1}", "c=", "{@c}", "b=", "{@b}", "a=", "@a"},
+			Want: []string{"1"},
+		},
+		{
+			Args:    []string{"2", "limit.depth", "{1}", "c=", "{@c}", "b=", "{@b}", "a=", "@a"},
+			WantErr: ErrCallDepth,
+		},
+		{
+			Args:    []string{"-1", "limit.depth"},
+			Want:    []string{"-1"},
+			WantErr: ErrIllegalValue,
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+}
+
+func TestStepLimit(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Args: []string{"7", "limit.steps", "{1 2 3 4 5 6}", "f=", "@f"},
+			Want: []string{"1", "2", "3", "4", "5", "6"},
+		},
+		{
+			Args:    []string{"6", "limit.steps", "{1 2 3 4 5 6}", "f=", "@f"},
+			Want:    []string{"1", "2", "3", "4", "5"},
+			WantErr: ErrTooManySteps,
+		},
+		{
+			// the budget is per top-level token
+			Args: []string{"4", "limit.steps", "{1 2}", "f=", "@f", "@f", "@f"},
+			Want: []string{"1", "2", "1", "2", "1", "2"},
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+}
+
+func TestTimeout(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	r.Register("loop", func(r *RPN) error {
+		for {
+			if err := r.ExecMacro("1 s.size"); err != nil {
+				return err
+			}
+			r.Clear()
+		}
+	}, CatProg, "")
+	err := r.ExecSlice([]string{"{loop}", "0.01", "timeout"})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("err=%v, want %v", err, ErrTimeout)
+	}
+	if !r.deadline.IsZero() {
+		t.Error("deadline was not cleared")
+	}
+	data := []UnitTestExecData{
+		{
+			Args: []string{"{1 2}", "1", "timeout"},
+			Want: []string{"1", "2"},
+		},
+		{
+			Args:    []string{"1", "1", "timeout"},
+			Want:    []string{"1", "1"},
+			WantErr: ErrExpectedAString,
+		},
+		{
+			Args:    []string{"{1}", "-1", "timeout"},
+			Want:    []string{"{1}", "-1"},
+			WantErr: ErrIllegalValue,
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+}
@@ -0,0 +1,389 @@
+package rpn
+
+import (
+	"mattwach/rpngo/parse"
+	"strconv"
+	"strings"
+)
+
+// LintIssue is a problem found by Lint
+type LintIssue struct {
+	// Line and Column start at 1
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (li LintIssue) String() string {
+	return strconv.Itoa(li.Line) + ":" + strconv.Itoa(li.Column) + ": " + li.Msg
+}
+
+// LintResult is returned by Lint
+type LintResult struct {
+	Issues []LintIssue
+	// EffectKnown is true if the stack effect of every token is known, in
+	// which case Pops and Pushes estimate the stack effect of the macro.
+	// Otherwise, UnknownEffect is the first token with an unknown effect.
+	EffectKnown   bool
+	Pops          int
+	Pushes        int
+	UnknownEffect string
+}
+
+type linter struct {
+	r   *RPN
+	src string
+	// variables set by the macro so far
+	vars map[string]bool
+	// user commands being linted, to avoid endless recursion
+	visiting map[string]bool
+	// false when linting a macro inside of braces, which does not
+	// change the stack effect
+	track    bool
+	depth    int
+	minDepth int
+	res      LintResult
+}
+
+// Lint checks a macro without running it.  It reports tokens that are not
+// commands, variables or numbers along with unterminated strings and
+// braces, and estimates how many values the macro pops and pushes.
+func (r *RPN) Lint(macro string) LintResult {
+	l := linter{
+		r:        r,
+		src:      macro,
+		vars:     make(map[string]bool),
+		visiting: make(map[string]bool),
+	}
+	return l.lint()
+}
+
+func (l *linter) lint() LintResult {
+	l.res.EffectKnown = true
+	l.lintMacro(0, len(l.src), true)
+	if l.res.EffectKnown {
+		l.res.Pops = -l.minDepth
+		l.res.Pushes = l.depth - l.minDepth
+	}
+	return l.res
+}
+
+// lintMacro lints src[start:end]
+func (l *linter) lintMacro(start, end int, track bool) {
+	saved := l.track
+	l.track = track
+	defer func() { l.track = saved }()
+	m := l.src[start:end]
+	params, body, err := splitMacroParams(m)
+	if err != nil {
+		l.issue(start, "missing ) after the parameters")
+		l.unknownEffect("(")
+		return
+	}
+	bodyStart := start + len(m) - len(body)
+	for _, p := range strings.Fields(params) {
+		l.checkName(p, start)
+		l.vars[p] = true
+		l.effect(1, 0)
+	}
+	at, err := parse.FieldsAt(body, func(field string, s, e int) error {
+		l.token(field, bodyStart+s, bodyStart+e)
+		return nil
+	})
+	if err != nil {
+		l.issue(bodyStart+at, err.Error())
+		l.unknownEffect(body[at:])
+	}
+}
+
+// token mirrors the dispatch order of Exec()
+func (l *linter) token(arg string, start, end int) {
+	r := l.r
+	if r.functions[arg] != nil {
+		if pops, pushes, ok := l.commandEffect(arg); ok {
+			l.effect(pops, pushes)
+		} else {
+			l.unknownEffect(arg)
+		}
+		return
+	}
+	if len(arg) > 1 {
+		switch arg[len(arg)-1] {
+		case '=':
+			if (len(arg) >= 3) && arg[len(arg)-2] == '=' {
+				l.setVar(arg[:len(arg)-2], start)
+				l.unknownEffect(arg)
+				return
+			}
+			l.setVar(arg[:len(arg)-1], start)
+			l.effect(1, 0)
+			return
+		case '/':
+			if isNum(rune(arg[0])) {
+				l.stackVar(arg[:len(arg)-1], start, -1)
+				return
+			}
+			l.checkName(arg[:len(arg)-1], start)
+			return
+		case '>':
+			if (len(arg) >= 3) && arg[len(arg)-2] == '>' {
+				l.checkVar(arg[:len(arg)-2], start)
+				l.unknownEffect(arg)
+				return
+			}
+			if isNum(rune(arg[0])) {
+				l.stackVar(arg[:len(arg)-1], start, 0)
+				return
+			}
+			l.checkVar(arg[:len(arg)-1], start)
+			l.effect(0, 1)
+			return
+		case '<':
+			if (len(arg) >= 3) && arg[len(arg)-2] == '<' {
+				l.setVar(arg[:len(arg)-2], start)
+				l.unknownEffect(arg)
+				return
+			}
+			if isNum(rune(arg[0])) {
+				l.stackVar(arg[:len(arg)-1], start, 0)
+				return
+			}
+			l.setVar(arg[:len(arg)-1], start)
+			l.effect(1, 0)
+			return
+		}
+		switch arg[0] {
+		case '$':
+			if (len(arg) >= 3) && arg[1] == '$' {
+				l.checkVar(arg[2:], start)
+				l.unknownEffect(arg)
+				return
+			}
+			if isNum(rune(arg[1])) {
+				l.stackVar(arg[1:], start, 1)
+				return
+			}
+			l.checkVar(arg[1:], start)
+			l.effect(0, 1)
+			return
+		case '@':
+			l.checkVar(arg[1:], start)
+			l.unknownEffect(arg)
+			return
+		case '`':
+			l.effect(1, 1)
+			return
+		}
+	}
+	if len(arg) >= 2 {
+		switch arg[len(arg)-1] {
+		case '"', '\'':
+			if arg[0] == arg[len(arg)-1] {
+				if arg[0] == '"' {
+					interpolatedNames(arg, func(offset int, name string) {
+						if !isNum(rune(name[0])) {
+							l.checkVar(name, start+offset)
+						}
+					})
+				}
+				l.effect(0, 1)
+				return
+			}
+		case '}':
+			if arg[0] == '{' {
+				l.lintMacro(start+1, end-1, false)
+				l.effect(0, 1)
+				return
+			}
+		case 'd', 'x', 'o', 'b':
+			if strings.IndexByte(arg, '>') < 0 {
+				if !isSuffixInt(arg) {
+					l.unknownToken(arg, start)
+					return
+				}
+				l.effect(0, 1)
+				return
+			}
+		}
+	}
+	if len(arg) > 0 && arg[len(arg)-1] == '?' {
+		return
+	}
+	if strings.Contains(arg, ">") {
+		parts := strings.SplitN(arg, ">", 2)
+		if _, err := r.conv.Convert(1, parts[0], parts[1]); err != nil {
+			l.issue(start, "unknown conversion: "+arg)
+		}
+		l.effect(1, 1)
+		return
+	}
+	if !isNumber(arg) {
+		l.unknownToken(arg, start)
+		return
+	}
+	l.effect(0, 1)
+}
+
+// commandEffect returns the stack effect of a command, if it is known from
+// its signature.  The effect of a command defined with def is estimated
+// from its macro.
+func (l *linter) commandEffect(name string) (int, int, bool) {
+	if sig := l.r.signatures[name]; sig != nil {
+		if sig.MoreIn || sig.MoreOut {
+			return 0, 0, false
+		}
+		return len(sig.In), len(sig.Out), true
+	}
+	uc, ok := l.r.userCommands[name]
+	if !ok || l.visiting[name] {
+		return 0, 0, false
+	}
+	l.visiting[name] = true
+	defer delete(l.visiting, name)
+	sub := linter{
+		r:        l.r,
+		src:      uc.macro,
+		vars:     make(map[string]bool),
+		visiting: l.visiting,
+	}
+	res := sub.lint()
+	return res.Pops, res.Pushes, res.EffectKnown
+}
+
+func (l *linter) effect(pops, pushes int) {
+	if !l.track || !l.res.EffectKnown {
+		return
+	}
+	l.depth -= pops
+	if l.depth < l.minDepth {
+		l.minDepth = l.depth
+	}
+	l.depth += pushes
+}
+
+func (l *linter) unknownEffect(arg string) {
+	if !l.track || !l.res.EffectKnown {
+		return
+	}
+	l.res.EffectKnown = false
+	l.res.UnknownEffect = arg
+}
+
+func (l *linter) unknownToken(arg string, start int) {
+	l.issue(start, "unknown command: "+arg)
+	l.unknownEffect(arg)
+}
+
+// stackVar checks a stack position such as the 2 in $2, 2/, 2> or 2<.
+// They all need the values down to the position, after which there are
+// delta more values.
+func (l *linter) stackVar(name string, start int, delta int) {
+	n, err := strconv.Atoi(name)
+	if err != nil {
+		l.issue(start, "illegal variable name: "+name)
+		l.unknownEffect(name)
+		return
+	}
+	l.effect(n+1, n+1+delta)
+}
+
+func (l *linter) setVar(name string, start int) {
+	if l.checkName(name, start) {
+		l.vars[name] = true
+	}
+}
+
+// checkVar reports variables that are not defined and not set earlier in
+// the macro
+func (l *linter) checkVar(name string, start int) {
+	if !l.checkName(name, start) {
+		return
+	}
+	if l.vars[name] {
+		return
+	}
+	if _, ok := l.r.variables[l.r.variableKey(name)]; ok {
+		return
+	}
+	l.issue(start, "unknown variable: "+name)
+}
+
+func (l *linter) checkName(name string, start int) bool {
+	if err := checkVariableName(name); err != nil {
+		l.issue(start, "illegal variable name: "+name)
+		return false
+	}
+	return true
+}
+
+func (l *linter) issue(offset int, msg string) {
+	line, col := parse.LineColumn(l.src, offset)
+	l.res.Issues = append(l.res.Issues, LintIssue{Line: line, Column: col, Msg: msg})
+}
+
+// isSuffixInt returns true if arg is an integer with a base suffix,
+// e.g. 10d, ffx, 17o, 101b
+func isSuffixInt(arg string) bool {
+	base := 10
+	switch arg[len(arg)-1] {
+	case 'x':
+		base = 16
+	case 'o':
+		base = 8
+	case 'b':
+		base = 2
+	}
+	_, err := strconv.ParseInt(arg[:len(arg)-1], base, 64)
+	return err == nil
+}
+
+// isNumber returns true if parseAndPushComplex() would accept arg
+func isNumber(arg string) bool {
+	if strings.HasSuffix(arg, "i") {
+		_, err := parseComplexWithI(arg)
+		return err == nil
+	}
+	if _, err := strconv.ParseFloat(arg, 64); err == nil {
+		return true
+	}
+	ltIdx := strings.IndexRune(arg, '<')
+	if (ltIdx < 0) || (ltIdx >= (len(arg) - 1)) {
+		return false
+	}
+	if _, err := strconv.ParseFloat(arg[:ltIdx], 64); err != nil {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg[ltIdx+1:], 64)
+	return err == nil
+}
+
+const lintHelp = "Pops a macro and checks it without running it.  Prints the " +
+	"line and column of tokens that are not commands, variables or numbers " +
+	"and of unterminated strings and braces.  Also prints how many values " +
+	"the macro pops and pushes, if that is known.\n" +
+	"Example: {(x) $x sq $y *} lint\n" +
+	"See Also: def, debug"
+
+func lint(r *RPN) error {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		return ErrExpectedAString
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	res := r.Lint(f.UnsafeString())
+	for _, issue := range res.Issues {
+		r.Println(issue.String())
+	}
+	if len(res.Issues) == 0 {
+		r.Println("no problems found")
+	}
+	if res.EffectKnown {
+		r.Println("stack effect: pops " + strconv.Itoa(res.Pops) + ", pushes " + strconv.Itoa(res.Pushes))
+	} else {
+		r.Println("stack effect: unknown after " + res.UnknownEffect)
+	}
+	return nil
+}
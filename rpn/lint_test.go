@@ -0,0 +1,191 @@
+package rpn
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	data := []struct {
+		macro      string
+		wantIssues []string
+		wantEffect string
+	}{
+		{
+			macro:      "1 2 x= $x",
+			wantEffect: "0 2",
+		},
+		{
+			macro:      "x= y=",
+			wantEffect: "2 0",
+		},
+		{
+			macro:      "(a b) $b $a",
+			wantEffect: "2 2",
+		},
+		{
+			macro:      "x< 1 x> x/",
+			wantEffect: "1 2",
+		},
+		{
+			macro:      "$0 $2",
+			wantEffect: "2 4",
+		},
+		{
+			macro:      "0/ 2/",
+			wantEffect: "4 2",
+		},
+		{
+			macro:      "1>",
+			wantEffect: "2 2",
+		},
+		{
+			macro:      "2<",
+			wantEffect: "3 3",
+		},
+		{
+			macro:      "$0 $1 0/ 1> 2<",
+			wantEffect: "2 3",
+		},
+		{
+			macro:      "$1x",
+			wantIssues: []string{"1:1: illegal variable name: 1x"},
+			wantEffect: "1x",
+		},
+		{
+			macro:      "1 ffx 101b 1e3 2+3i 1<90 -5",
+			wantEffect: "0 7",
+		},
+		{
+			macro:      "1 `m 'a' \"b\"",
+			wantEffect: "0 3",
+		},
+		{
+			macro:      "1 km>mi",
+			wantEffect: "0 1",
+		},
+		{
+			macro:      "1 km>foo",
+			wantIssues: []string{"1:3: unknown conversion: km>foo"},
+			wantEffect: "0 1",
+		},
+		{
+			macro:      "1 foo 2",
+			wantIssues: []string{"1:3: unknown command: foo"},
+			wantEffect: "foo",
+		},
+		{
+			macro:      "12z zzx",
+			wantIssues: []string{"1:1: unknown command: 12z", "1:5: unknown command: zzx"},
+			wantEffect: "12z",
+		},
+		{
+			macro:      "$nope",
+			wantIssues: []string{"1:1: unknown variable: nope"},
+			wantEffect: "0 1",
+		},
+		{
+			macro:      "1 1x=",
+			wantIssues: []string{"1:3: illegal variable name: 1x"},
+			wantEffect: "0 0",
+		},
+		{
+			macro:      "{1 bar}\n  {$y} 5",
+			wantIssues: []string{"1:4: unknown command: bar", "2:4: unknown variable: y"},
+			wantEffect: "0 3",
+		},
+		{
+			macro:      "1\n  {2 'x'",
+			wantIssues: []string{"2:3: unterminatd brace"},
+			wantEffect: "{2 'x'",
+		},
+		{
+			macro:      "1 'abc",
+			wantIssues: []string{"1:3: unterminated single quote"},
+			wantEffect: "'abc",
+		},
+		{
+			macro:      "(a b 1 2",
+			wantIssues: []string{"1:1: missing ) after the parameters"},
+			wantEffect: "(",
+		},
+		{
+			macro:      "1 2 x== $$x",
+			wantEffect: "x==",
+		},
+		{
+			macro:      "1 @f",
+			wantIssues: []string{"1:3: unknown variable: f"},
+			wantEffect: "@f",
+		},
+		{
+			macro:      "1 2 3 two",
+			wantEffect: "0 1",
+		},
+		{
+			macro:      "1 rec",
+			wantEffect: "rec",
+		},
+		{
+			macro:      "1 undo",
+			wantEffect: "undo",
+		},
+		{
+			macro:      "undo?",
+			wantEffect: "0 0",
+		},
+	}
+	for _, d := range data {
+		var r RPN
+		r.Init(256)
+		if err := r.ExecSlice([]string{"{x= y=}", "''", "'two'", "def", "{rec}", "''", "'rec'", "def"}); err != nil {
+			t.Fatal(err)
+		}
+		res := r.Lint(d.macro)
+		var issues []string
+		for _, issue := range res.Issues {
+			issues = append(issues, issue.String())
+		}
+		if !reflect.DeepEqual(issues, d.wantIssues) {
+			t.Errorf("Lint(%q) issues=%q, want %q", d.macro, issues, d.wantIssues)
+		}
+		effect := res.UnknownEffect
+		if res.EffectKnown {
+			effect = strconv.Itoa(res.Pops) + " " + strconv.Itoa(res.Pushes)
+		}
+		if effect != d.wantEffect {
+			t.Errorf("Lint(%q) effect=%q, want %q", d.macro, effect, d.wantEffect)
+		}
+	}
+}
+
+func TestLintCommand(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	var out strings.Builder
+	r.Print = func(msg string) { out.WriteString(msg) }
+	if err := r.ExecSlice([]string{"5", "{x= foo}", "lint"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "1:4: unknown command: foo\nstack effect: unknown after foo\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	out.Reset()
+	if err := r.ExecSlice([]string{"{x= 1 2}", "lint"}); err != nil {
+		t.Fatal(err)
+	}
+	want = "no problems found\nstack effect: pops 1, pushes 2\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(r.Frames) != 1 {
+		t.Errorf("lint changed the stack: %v", r.Frames)
+	}
+	if err := r.ExecSlice([]string{"1", "lint"}); !errors.Is(err, ErrExpectedAString) {
+		t.Errorf("err=%v, want %v", err, ErrExpectedAString)
+	}
+}
@@ -0,0 +1,313 @@
+package rpn
+
+import (
+	"mattwach/rpngo/elog"
+	"mattwach/rpngo/parse"
+	"strconv"
+	"strings"
+)
+
+// maxMacroCache is the number of compiled macros to keep.  When the cache
+// fills up, it is simply cleared.
+const maxMacroCache = 32
+
+type tokenKind uint8
+
+const (
+	tokExec tokenKind = iota // not resolved, use Exec()
+	tokFunction
+	tokPush
+	tokGetVariable
+	tokSetVariable
+	tokExecVariable
+)
+
+type macroToken struct {
+	kind  tokenKind
+	arg   string
+	name  string
+	fn    func(*RPN) error
+	frame Frame
+}
+
+// Macro is a parsed macro with functions, literals and variable operations
+// resolved ahead of time so that it can be executed repeatedly (e.g. in a
+// loop or plot) without parsing it again.
+type Macro struct {
+	args   []string
+	params []string
+	tokens []macroToken
+	// functions generation the tokens were resolved against
+	gen uint32
+}
+
+// ExecMacro executes a macro string.  A macro can start with a parameter
+// list, e.g. {(x y) $x $y *}.  Parameters are popped from the stack into
+// local variables that shadow any existing variables with the same name
+// until the macro returns.
+func (r *RPN) ExecMacro(macro string) error {
+	m, err := r.CompileMacro(macro)
+	if err != nil {
+		return r.newExecError(err)
+	}
+	return r.ExecCompiled(m)
+}
+
+// CompileMacro parses a macro string, returning a cached copy if the same
+// string was compiled recently.  Since the cache is keyed by the macro text,
+// changing a variable that holds a macro simply results in a cache miss.
+func (r *RPN) CompileMacro(macro string) (*Macro, error) {
+	if m := r.macroCache[macro]; m != nil {
+		return m, nil
+	}
+	params, body, err := splitMacroParams(macro)
+	if err != nil {
+		return nil, err
+	}
+	elog.Heap("alloc: rpn/macro.go:69: m := &Macro{params: strings.Fields(params)}")
+	m := &Macro{params: strings.Fields(params)} // object allocated on the heap: escapes at line 69
+	for _, name := range m.params {
+		if err := checkVariableName(name); err != nil {
+			return nil, err
+		}
+	}
+	addField := func(t string) error {
+		m.args = append(m.args, t)
+		return nil
+	}
+	if err := parse.Fields(body, addField); err != nil {
+		return nil, err
+	}
+	r.resolveTokens(m)
+	if len(r.macroCache) >= maxMacroCache {
+		for k := range r.macroCache {
+			delete(r.macroCache, k)
+		}
+	}
+	r.macroCache[macro] = m
+	return m, nil
+}
+
+// CompileFields creates an uncached macro from already parsed fields.
+func (r *RPN) CompileFields(fields []string) *Macro {
+	elog.Heap("alloc: rpn/macro.go:95: m := &Macro{args: make([]string, len(fields))}")
+	m := &Macro{args: make([]string, len(fields))} // object allocated on the heap: escapes at line 95
+	copy(m.args, fields)
+	r.resolveTokens(m)
+	return m
+}
+
+// ExecCompiled executes a compiled macro
+func (r *RPN) ExecCompiled(m *Macro) error {
+	return r.execCompiled(m, "")
+}
+
+// execCompiled executes a compiled macro.  name is the variable the macro
+// came from, if any, and is used for error traces.
+func (r *RPN) execCompiled(m *Macro, name string) error {
+	if m.gen != r.funcGen {
+		// functions were registered since the macro was compiled
+		r.resolveTokens(m)
+	}
+	if len(m.params) > 0 {
+		if len(r.Frames) < len(m.params) {
+			return ErrNotEnoughStackFrames
+		}
+		names := m.params
+		if len(r.namespace) > 0 {
+			// parameters are local to the module too
+			elog.Heap("alloc: rpn/macro.go:120: names = make([]string, len(m.params))")
+			names = make([]string, len(m.params)) // object allocated on the heap: size is not constant
+			for i, name := range m.params {
+				names[i] = r.scopedName(name)
+			}
+		}
+		// the depth of each variable, then the previous local base of each
+		// variable (-1 if it was not a parameter)
+		elog.Heap("alloc: rpn/macro.go:126: saved := make([]int, 2*len(names))")
+		saved := make([]int, 2*len(names)) // object allocated on the heap: size is not constant
+		for i, name := range names {
+			saved[i] = len(r.variables[name])
+			saved[len(names)+i] = -1
+			if base, ok := r.locals[name]; ok {
+				saved[len(names)+i] = base
+			}
+		}
+		defer r.restoreLocals(names, saved)
+		args := r.Frames[len(r.Frames)-len(names):]
+		for i, name := range names {
+			if err := r.pushLocal(name, args[i]); err != nil {
+				return err
+			}
+			r.locals[name] = saved[i]
+		}
+		r.Frames = r.Frames[:len(r.Frames)-len(names)]
+	}
+	if err := r.enterMacro(); err != nil {
+		return err
+	}
+	defer r.leaveMacro()
+	for i := range m.tokens {
+		if r.debug.stepping {
+			if err := r.debugPause(m, i); err != nil {
+				return err
+			}
+		}
+		if err := r.execToken(&m.tokens[i]); err != nil {
+			return r.wrapExecError(err, name, m.args, i, parse.ErrorContext(m.args, i))
+		}
+	}
+	return nil
+}
+
+func (r *RPN) leaveMacro() {
+	r.callDepth--
+	if r.callDepth == 0 {
+		// a bp near the end of a macro should not carry over to the next one
+		r.debug.stepping = false
+	}
+}
+
+func (r *RPN) execToken(t *macroToken) error {
+	if t.kind == tokExec {
+		return r.Exec(t.arg)
+	}
+	if r.Interrupt() {
+		return ErrInterrupted
+	}
+	if err := r.countStep(); err != nil {
+		return err
+	}
+	switch t.kind {
+	case tokFunction:
+		return r.execProfiled(t.arg, t.fn)
+	case tokPush:
+		return r.PushFrame(t.frame)
+	case tokGetVariable:
+		f, err := r.GetVariable(t.name)
+		if err != nil {
+			return err
+		}
+		return r.PushFrame(f)
+	case tokSetVariable:
+		return r.SetVariable(t.name)
+	case tokExecVariable:
+		return r.execVariableProfiled(t.arg, t.name)
+	}
+	return r.Exec(t.arg)
+}
+
+func (r *RPN) resolveTokens(m *Macro) {
+	if len(m.tokens) != len(m.args) {
+		elog.Heap("alloc: rpn/macro.go:175: m.tokens = make([]macroToken, len(m.args))")
+		m.tokens = make([]macroToken, len(m.args)) // object allocated on the heap: size is not constant
+	}
+	for i, arg := range m.args {
+		m.tokens[i] = r.resolveToken(arg)
+	}
+	m.gen = r.funcGen
+}
+
+// resolveToken mirrors the dispatch order of Exec() for the cases that can
+// be decided ahead of time.  Everything else is left to Exec().
+func (r *RPN) resolveToken(arg string) macroToken {
+	t := macroToken{kind: tokExec, arg: arg}
+	if fn := r.functions[arg]; fn != nil {
+		t.kind = tokFunction
+		t.fn = fn
+		return t
+	}
+	if len(arg) > 1 {
+		switch arg[len(arg)-1] {
+		case '=':
+			if arg[len(arg)-2] != '=' {
+				t.kind = tokSetVariable
+				t.name = arg[:len(arg)-1]
+			}
+			return t
+		case '/', '>', '<':
+			return t
+		}
+		switch arg[0] {
+		case '$':
+			if arg[1] != '$' {
+				t.kind = tokGetVariable
+				t.name = arg[1:]
+			}
+			return t
+		case '@':
+			t.kind = tokExecVariable
+			t.name = arg[1:]
+			return t
+		case '`':
+			return t
+		}
+	}
+	if len(arg) >= 2 {
+		switch arg[len(arg)-1] {
+		case '"':
+			if (arg[0] == '"') && !strings.Contains(arg, "${") {
+				// interpolated strings are left to Exec()
+				t.kind = tokPush
+				t.frame = StringFrame(arg[1:len(arg)-1], STRING_DOUBLEQ_FRAME)
+			}
+			return t
+		case '\'':
+			if arg[0] == '\'' {
+				t.kind = tokPush
+				t.frame = StringFrame(arg[1:len(arg)-1], STRING_SINGLEQ_FRAME)
+			}
+			return t
+		case '}':
+			if arg[0] == '{' {
+				t.kind = tokPush
+				t.frame = StringFrame(arg[1:len(arg)-1], STRING_BRACE_FRAME)
+			}
+			return t
+		}
+	}
+	if strings.ContainsAny(arg, "?>i<dxob") {
+		// help, conversions, complex, polar and integer suffixes
+		return t
+	}
+	if fv, err := strconv.ParseFloat(arg, 64); err == nil {
+		t.kind = tokPush
+		t.frame = ComplexFrame(complex(fv, 0))
+	}
+	return t
+}
+
+// restoreLocals discards any values pushed to the named variables since
+// saved was recorded and restores the local bases of outer macros.
+func (r *RPN) restoreLocals(names []string, saved []int) {
+	for i, name := range names {
+		r.truncateVariable(name, saved[i])
+		if base := saved[len(names)+i]; base >= 0 {
+			r.locals[name] = base
+		} else {
+			delete(r.locals, name)
+		}
+	}
+}
+
+// localBase returns the index of the first value of variable key that
+// belongs to the running macro.  For a macro parameter, the values below
+// it belong to the caller and are left alone by the operations that work
+// on all of the values, such as x== and x>>.  It is 0 for other variables.
+func (r *RPN) localBase(key string) int {
+	return r.locals[key]
+}
+
+// splitMacroParams separates an optional leading (a b c) parameter list
+// from the rest of the macro.
+func splitMacroParams(macro string) (string, string, error) {
+	trimmed := strings.TrimLeft(macro, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '(' {
+		return "", macro, nil
+	}
+	end := strings.IndexByte(trimmed, ')')
+	if end < 0 {
+		return "", "", ErrSyntax
+	}
+	return trimmed[1:end], trimmed[end+1:], nil
+}
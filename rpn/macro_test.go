@@ -0,0 +1,191 @@
+package rpn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMacroParams(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Args: []string{"{1 2}", "f=", "@f"},
+			Want: []string{"1", "2"},
+		},
+		{
+			Args: []string{"{(a b) $b $a}", "swap=", "1", "2", "@swap"},
+			Want: []string{"2", "1"},
+		},
+		{
+			Args: []string{"{ ( a b ) $b $a }", "swap=", "1", "2", "@swap"},
+			Want: []string{"2", "1"},
+		},
+		{
+			Args: []string{"{() 3}", "f=", "@f"},
+			Want: []string{"3"},
+		},
+		{
+			Name: "shadow",
+			Args: []string{"5", "a=", "{(a) $a}", "f=", "7", "@f", "$a"},
+			Want: []string{"7", "5"},
+		},
+		{
+			Name: "local set",
+			Args: []string{"5", "a=", "{(a) 9 a= $a}", "f=", "7", "@f", "$a"},
+			Want: []string{"9", "5"},
+		},
+		{
+			Name: "discarded",
+			Args: []string{"{(a) $a}", "f=", "7", "@f", "'a'", "v.exists"},
+			Want: []string{"7", "false"},
+		},
+		{
+			Name: "nested",
+			Args: []string{"{(a) $a}", "g=", "{(a) 2 @g $a}", "f=", "1", "@f"},
+			Want: []string{"2", "1"},
+		},
+		{
+			Name: "local clear",
+			Args: []string{"10", "x=", "{(x) x/}", "g=", "5", "@g", "$x"},
+			Want: []string{"10"},
+		},
+		{
+			Name:    "local clear only once",
+			Args:    []string{"10", "x=", "{(x) x/ x/}", "g=", "5", "@g"},
+			WantErr: ErrNotFound,
+		},
+		{
+			Name: "set after local clear",
+			Args: []string{"10", "x=", "{(x) x/ 6 x= $x}", "g=", "5", "@g", "$x"},
+			Want: []string{"6", "10"},
+		},
+		{
+			Name: "local move all",
+			Args: []string{"10", "x=", "{(x) 3 x== $x}", "g=", "5", "@g", "$x"},
+			Want: []string{"3", "10"},
+		},
+		{
+			Name: "local push all",
+			Args: []string{"10", "x=", "{(x) $$x}", "g=", "5", "@g", "$x"},
+			Want: []string{"5", "10"},
+		},
+		{
+			Name: "local append all",
+			Args: []string{"10", "x=", "{(x) 3 4 x<< $$x}", "g=", "5", "@g", "$$x"},
+			Want: []string{"5", "3", "4", "10"},
+		},
+		{
+			Name: "local pop all",
+			Args: []string{"10", "x=", "{(x) 6 x< x>> 'x' v.exists}", "g=", "5", "@g", "$x"},
+			Want: []string{"5", "6", "true", "10"},
+		},
+		{
+			Name:    "local pop",
+			Args:    []string{"10", "x=", "{(x) x> x>}", "g=", "5", "@g"},
+			Want:    []string{"5"},
+			WantErr: ErrNotFound,
+		},
+		{
+			Name: "nested locals",
+			Args: []string{"{(x) $$x}", "h=", "{(x) 2 @h $$x}", "g=", "1", "@g"},
+			Want: []string{"2", "1"},
+		},
+		{
+			Args:    []string{"{(a b) $a}", "f=", "1", "@f"},
+			Want:    []string{"1"},
+			WantErr: ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"{(1a) $a}", "f=", "1", "@f"},
+			Want:    []string{"1"},
+			WantErr: ErrIllegalName,
+		},
+		{
+			Args:    []string{"{(a $a}", "f=", "1", "@f"},
+			Want:    []string{"1"},
+			WantErr: ErrSyntax,
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+}
+
+func TestMacroParamsDiscardedOnError(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	err := r.ExecSlice([]string{"5", "a=", "{(a b) $a $b notacommand}", "f=", "1", "2", "@f"})
+	if !errors.Is(err, ErrSyntax) {
+		t.Fatalf("err=%v, want %v", err, ErrSyntax)
+	}
+	a, err := r.GetVariable("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.String(false) != "5" {
+		t.Errorf("a=%v, want 5", a.String(false))
+	}
+	if _, err := r.GetVariable("b"); err != ErrNotFound {
+		t.Errorf("b err=%v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestCompileMacro(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	m, err := r.CompileMacro("1 'a' {b} x= $x @x x> s.size 3d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tokenKind{
+		tokPush, tokPush, tokPush, tokSetVariable, tokGetVariable,
+		tokExecVariable, tokExec, tokFunction, tokExec,
+	}
+	if len(m.tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(m.tokens), len(want))
+	}
+	for i, tok := range m.tokens {
+		if tok.kind != want[i] {
+			t.Errorf("token %d (%s) kind=%d, want %d", i, tok.arg, tok.kind, want[i])
+		}
+	}
+	m2, err := r.CompileMacro("1 'a' {b} x= $x @x x> s.size 3d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != m2 {
+		t.Error("expected the cached macro to be returned")
+	}
+}
+
+func TestCompiledMacroSeesNewFunctions(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	m, err := r.CompileMacro("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ExecCompiled(m); !errors.Is(err, ErrSyntax) {
+		t.Fatalf("err=%v, want %v", err, ErrSyntax)
+	}
+	r.Register("foo", func(r *RPN) error { return r.PushFrame(RealFrame(1)) }, CatProg, "")
+	if err := r.ExecCompiled(m); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Frames) != 1 {
+		t.Errorf("stack size=%d, want 1", len(r.Frames))
+	}
+}
+
+func TestCompiledMacroError(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	err := r.ExecMacro("1 foo 2")
+	if !errors.Is(err, ErrSyntax) {
+		t.Fatalf("err=%v, want %v", err, ErrSyntax)
+	}
+	want := "1 ->foo<- 2: " + ErrSyntax.Error()
+	if err.Error() != want {
+		t.Errorf("err=%q, want %q", err.Error(), want)
+	}
+	if _, err := r.CompileMacro("1 'foo"); err == nil {
+		t.Error("expected a parse error")
+	}
+}
@@ -0,0 +1,85 @@
+package rpn
+
+import (
+	"mattwach/rpngo/parse"
+	"strings"
+)
+
+// Import runs the source returned by load as a module.  A module is only
+// loaded once, further imports of the same name do nothing.  While the
+// module runs, variables and commands it defines without a '.' in their
+// name are prefixed with the module name, so mean= in module stats
+// creates stats.mean.
+func (r *RPN) Import(name string, load func() (string, error)) error {
+	if err := checkModuleName(name); err != nil {
+		return err
+	}
+	if r.modules[name] {
+		return nil
+	}
+	src, err := load()
+	if err != nil {
+		return err
+	}
+	// marking the module first prevents modules that import each other
+	// from looping forever
+	r.modules[name] = true
+	saved := r.setNamespace(name)
+	err = parse.Fields(src, r.Exec)
+	r.setNamespace(saved)
+	if err != nil {
+		// allow the import to be retried after the problem is fixed
+		delete(r.modules, name)
+	}
+	return err
+}
+
+func checkModuleName(name string) error {
+	if err := checkVariableName(name); err != nil {
+		return err
+	}
+	if strings.IndexByte(name, '.') >= 0 {
+		return ErrIllegalName
+	}
+	return nil
+}
+
+// setNamespace makes ns the current namespace and returns the previous
+// one.  An empty ns means no namespace.
+func (r *RPN) setNamespace(ns string) string {
+	saved := r.namespace
+	r.namespace = ns
+	return saved
+}
+
+// scopedName returns the name that a variable or command defined in the
+// current namespace is stored under.  Names that contain a '.' are never
+// prefixed so that modules can still set global and special variables.
+func (r *RPN) scopedName(name string) string {
+	if (len(r.namespace) == 0) || (strings.IndexByte(name, '.') >= 0) {
+		return name
+	}
+	return r.namespace + "." + name
+}
+
+// variableKey returns the key a variable is stored under.  Inside a
+// namespace, the module's own variable is preferred over a global one.
+func (r *RPN) variableKey(name string) string {
+	if scoped := r.scopedName(name); (len(scoped) != len(name)) && (len(r.variables[scoped]) > 0) {
+		return scoped
+	}
+	return name
+}
+
+// moduleOf returns the imported module that a qualified name such as
+// stats.mean belongs to, or "" if it does not belong to one.
+func (r *RPN) moduleOf(name string) string {
+	idx := strings.IndexByte(name, '.')
+	if idx <= 0 {
+		return ""
+	}
+	if !r.modules[name[:idx]] {
+		return ""
+	}
+	return name[:idx]
+}
@@ -0,0 +1,189 @@
+package rpn
+
+import "testing"
+
+var testModules = map[string]string{
+	"geom": "{(r) $r $pi} 'Pushes r and pi' 'carea' def\n" +
+		"3.14 pi=\n" +
+		"{$pi} tau=\n" +
+		"{(r) $r @tau} 'Pushes r and tau' 'circ' def\n",
+	"counter":  "0 count= {$step count=} 'Sets count to step' 'inc' def",
+	"shadow":   "1 x= {(x) $x} 'Pushes x' 'px' def",
+	"bad":      "1 2 foo",
+	"loop":     "'loop' imp 1 x=",
+	"ops":      "2 b== 3 4 c<< 6 e= e/ 7 f= f/",
+	"stackops": "1 2 p<< $$p p>>",
+	"cleanup":  "{1} '' 'tmp' def 'tmp' undef",
+}
+
+func importTestModule(r *RPN) error {
+	f, err := r.PopFrame()
+	if err != nil {
+		return err
+	}
+	name := f.UnsafeString()
+	return r.Import(name, func() (string, error) {
+		src, ok := testModules[name]
+		if !ok {
+			return "", ErrNotFound
+		}
+		return src, nil
+	})
+}
+
+func TestImport(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Name: "prefixed names",
+			Args: []string{"'geom'", "imp", "2", "geom.carea", "$geom.pi"},
+			Want: []string{"2", "3.14", "3.14"},
+		},
+		{
+			Name: "module variables do not touch globals",
+			Args: []string{"3", "pi=", "'geom'", "imp", "$pi"},
+			Want: []string{"3"},
+		},
+		{
+			Name: "commands use module variables",
+			Args: []string{"3", "pi=", "'geom'", "imp", "1", "geom.circ"},
+			Want: []string{"1", "3.14"},
+		},
+		{
+			Name: "macro variables use module variables",
+			Args: []string{"3", "pi=", "'geom'", "imp", "@geom.tau"},
+			Want: []string{"3.14"},
+		},
+		{
+			Name: "commands read globals",
+			Args: []string{"5", "step=", "9", "count=", "'counter'", "imp", "counter.inc", "$count", "$counter.count"},
+			Want: []string{"9", "5"},
+		},
+		{
+			Name: "loaded once",
+			Args: []string{"'counter'", "imp", "5", "step=", "counter.inc", "'counter'", "imp", "$counter.count"},
+			Want: []string{"5"},
+		},
+		{
+			Name: "parameters hide module variables",
+			Args: []string{"'shadow'", "imp", "7", "shadow.px", "$shadow.x"},
+			Want: []string{"7", "1"},
+		},
+		{
+			Name: "recursive import",
+			Args: []string{"'loop'", "imp", "$loop.x"},
+			Want: []string{"1"},
+		},
+		{
+			Name: "stack and clear operations use module variables",
+			Args: []string{"5", "f=", "'ops'", "imp", "$f", "$$ops.b", "$$ops.c", "'ops.e'", "v.exists", "'ops.f'", "v.exists", "'b'", "v.exists", "'c'", "v.exists"},
+			Want: []string{"5", "2", "3", "4", "false", "false", "false", "false"},
+		},
+		{
+			Name: "push all and move all use module variables",
+			Args: []string{"9", "p=", "'stackops'", "imp", "$p", "'stackops.p'", "v.exists"},
+			Want: []string{"1", "2", "1", "2", "9", "false"},
+		},
+		{
+			Name:    "clear does not touch globals",
+			Args:    []string{"5", "f=", "{f/}", "'Clears f'", "'clr'", "'ops'", "def.module", "ops.clr"},
+			WantErr: ErrNotFound,
+		},
+		{
+			Name: "undef does not touch global commands",
+			Args: []string{"{5}", "''", "'tmp'", "def", "'cleanup'", "imp", "tmp"},
+			Want: []string{"5"},
+		},
+		{
+			Name:    "undef removes module commands",
+			Args:    []string{"'cleanup'", "imp", "cleanup.tmp"},
+			WantErr: ErrSyntax,
+		},
+		{
+			Name:    "not found",
+			Args:    []string{"'nope'", "imp"},
+			WantErr: ErrNotFound,
+		},
+		{
+			Name:    "illegal name",
+			Args:    []string{"'a.b'", "imp"},
+			WantErr: ErrIllegalName,
+		},
+		{
+			Name:    "error",
+			Args:    []string{"'bad'", "imp"},
+			Want:    []string{"1", "2"},
+			WantErr: ErrSyntax,
+		},
+	}
+	UnitTestExecAll(t, data, func(r *RPN) {
+		r.Register("imp", importTestModule, CatProg, "")
+	})
+}
+
+func TestImportRetryAfterError(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	r.Register("imp", importTestModule, CatProg, "")
+	if err := r.ExecSlice([]string{"'bad'", "imp"}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if r.modules["bad"] {
+		t.Error("module should not be marked as loaded after an error")
+	}
+	if len(r.namespace) > 0 {
+		t.Errorf("namespace=%q, want empty", r.namespace)
+	}
+}
+
+func TestModuleDefSnapshot(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	r.Register("imp", importTestModule, CatProg, "")
+	if err := r.ExecSlice([]string{"'geom'", "imp"}); err != nil {
+		t.Fatal(err)
+	}
+	got := string(r.DefSnapshot(nil))
+	want := "{(r) $r $pi} 'Pushes r and pi' 'carea' 'geom' def.module\n" +
+		"{(r) $r @tau} 'Pushes r and tau' 'circ' 'geom' def.module\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// the restored commands still find the module's variables
+	var r2 RPN
+	r2.Init(256)
+	if err := r2.ExecSlice([]string{"3.14", "geom.pi=", "{$pi}", "geom.tau="}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.ExecMacro(got); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.ExecSlice([]string{"2", "geom.circ"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r2.Frames) != 2 || r2.Frames[1].String(false) != "3.14" {
+		t.Errorf("got %v, want 2 3.14", r2.Frames)
+	}
+}
+
+func TestDefModule(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Name: "define",
+			Args: []string{"5", "geom.x=", "{$x}", "''", "'px'", "'geom'", "def.module", "geom.px"},
+			Want: []string{"5"},
+		},
+		{
+			Name:    "illegal module name",
+			Args:    []string{"{}", "''", "'px'", "'a.b'", "def.module"},
+			Want:    []string{"{}", "''", "'px'", "'a.b'"},
+			WantErr: ErrIllegalName,
+		},
+		{
+			Name:    "not enough args",
+			Args:    []string{"''", "'px'", "'geom'", "def.module"},
+			Want:    []string{"''", "'px'", "'geom'"},
+			WantErr: ErrNotEnoughStackFrames,
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+}
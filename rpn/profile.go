@@ -0,0 +1,128 @@
+package rpn
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type profileEntry struct {
+	name  string
+	calls int
+	total time.Duration
+}
+
+// profiler accumulates call counts and wall time for commands and
+// @macros.  Times are inclusive, so a macro's time includes the
+// commands it calls.
+type profiler struct {
+	entries map[string]*profileEntry
+}
+
+func (p *profiler) record(name string, t0 time.Time) {
+	e := p.entries[name]
+	if e == nil {
+		e = &profileEntry{name: name}
+		p.entries[name] = e
+	}
+	e.calls++
+	e.total += time.Since(t0)
+}
+
+// execProfiled calls fn, recording it under name if profiling is enabled
+func (r *RPN) execProfiled(name string, fn func(*RPN) error) error {
+	p := r.prof
+	if p == nil {
+		return fn(r)
+	}
+	t0 := time.Now()
+	err := fn(r)
+	if r.prof == p {
+		// not recorded if fn stopped or restarted the profiler
+		p.record(name, t0)
+	}
+	return err
+}
+
+// execVariableProfiled is execProfiled for @name
+func (r *RPN) execVariableProfiled(arg string, name string) error {
+	p := r.prof
+	if p == nil {
+		return r.execVariableAsMacro(name)
+	}
+	t0 := time.Now()
+	err := r.execVariableAsMacro(name)
+	if r.prof == p {
+		p.record(arg, t0)
+	}
+	return err
+}
+
+const profStartHelp = "Starts the profiler, clearing any previous results.  " +
+	"While running, the number of calls and time spent is recorded for " +
+	"every command and @macro.\n" +
+	"Example: prof.start 'sin(x)' plot prof.stop prof.report\n" +
+	"See Also: prof.stop, prof.report"
+
+func profStart(r *RPN) error {
+	r.prof = &profiler{entries: make(map[string]*profileEntry)}
+	r.profResults = r.prof
+	return nil
+}
+
+const profStopHelp = "Stops the profiler.  Results are kept for prof.report\n" +
+	"See Also: prof.start, prof.report"
+
+func profStop(r *RPN) error {
+	r.prof = nil
+	return nil
+}
+
+const profReportHelp = "Prints the profiler results, sorted by total time.  " +
+	"Times include the time spent in any commands that were called.\n" +
+	"See Also: prof.start, prof.stop"
+
+func profReport(r *RPN) error {
+	if r.profResults == nil {
+		return ErrNotFound
+	}
+	entries := make([]*profileEntry, 0, len(r.profResults.entries))
+	nameWidth := len("name")
+	for _, e := range r.profResults.entries {
+		entries = append(entries, e)
+		if len(e.name) > nameWidth {
+			nameWidth = len(e.name)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].total != entries[j].total {
+			return entries[i].total > entries[j].total
+		}
+		return entries[i].name < entries[j].name
+	})
+	r.Println(profileRow(nameWidth, "name", "calls", "total ms", "avg us"))
+	for _, e := range entries {
+		ms := float64(e.total) / float64(time.Millisecond)
+		us := float64(e.total) / float64(time.Microsecond) / float64(e.calls)
+		r.Println(profileRow(
+			nameWidth,
+			e.name,
+			strconv.Itoa(e.calls),
+			strconv.FormatFloat(ms, 'f', 1, 64),
+			strconv.FormatFloat(us, 'f', 1, 64)))
+	}
+	return nil
+}
+
+func profileRow(nameWidth int, name, calls, total, avg string) string {
+	return name + strings.Repeat(" ", nameWidth-len(name)) +
+		padLeft(calls, 9) + padLeft(total, 11) + padLeft(avg, 11)
+}
+
+func padLeft(s string, width int) string {
+	if len(s) >= width {
+		return " " + s
+	}
+	return strings.Repeat(" ", width-len(s)) + s
+}
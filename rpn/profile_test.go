@@ -0,0 +1,58 @@
+package rpn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	args := []string{
+		"{s.size}", "f=", "{@f @f}", "g=",
+		"prof.start", "@g", "@g", "@g", "prof.stop",
+		"@g",
+	}
+	if err := r.ExecSlice(args); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"@g": 3, "@f": 6, "s.size": 6}
+	got := r.profResults.entries
+	if len(got) != len(want) {
+		t.Errorf("got %d entries, want %d", len(got), len(want))
+	}
+	for name, calls := range want {
+		e := got[name]
+		if e == nil {
+			t.Errorf("%s: missing", name)
+			continue
+		}
+		if e.calls != calls {
+			t.Errorf("%s: calls=%d, want %d", name, e.calls, calls)
+		}
+	}
+}
+
+func TestProfileReport(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	var out strings.Builder
+	r.Print = func(msg string) { out.WriteString(msg) }
+	if err := r.Exec("prof.report"); err != ErrNotFound {
+		t.Errorf("err=%v, want %v", err, ErrNotFound)
+	}
+	if err := r.ExecSlice([]string{"prof.start", "s.size", "s.size", "prof.report"}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), out.String())
+	}
+	if !strings.HasPrefix(lines[0], "name") {
+		t.Errorf("header=%q", lines[0])
+	}
+	fields := strings.Fields(lines[1])
+	if (len(fields) != 4) || (fields[0] != "s.size") || (fields[1] != "2") {
+		t.Errorf("row=%q", lines[1])
+	}
+}
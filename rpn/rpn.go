@@ -4,22 +4,54 @@ import (
 	"mattwach/rpngo/convert"
 	"mattwach/rpngo/elog"
 	"sort"
+	"time"
 )
 
 // RPN is the main structure
 type RPN struct {
 	Frames    []Frame
 	variables map[string][]Frame
+	// index of the first value of each macro parameter in its variable.
+	// Values below it belong to the caller.
+	locals    map[string]int
 	functions map[string]func(*RPN) error
+	// optional signatures of the functions, see SetSignature
+	signatures map[string]*Signature
 	// maps are category -> command -> help
 	help          map[string]map[string]string
 	Interrupt     func() bool
 	Print         func(string)
 	Input         func(*RPN) (string, error)
+	DebugPrompt   func(*RPN, DebugState) (DebugAction, error)
 	TextWidth     int
 	maxStackDepth int
 	AngleUnit     FrameType
-	conv          *convert.Conversion
+	// MaxCallDepth limits nested macro calls, 0 for no limit
+	MaxCallDepth int
+	// MaxSteps limits the number of tokens a top-level token can execute,
+	// 0 for no limit
+	MaxSteps   int
+	conv       *convert.Conversion
+	macroCache map[string]*Macro
+	// commands created with def
+	userCommands map[string]userCommand
+	// modules loaded with Import and the namespace of the running module
+	modules   map[string]bool
+	namespace string
+	// incremented when functions are registered so compiled macros
+	// know to resolve their tokens again
+	funcGen   uint32
+	callDepth int
+	debug     debugState
+	// prof is nil unless the profiler is running
+	prof        *profiler
+	profResults *profiler
+	lastError   *ExecError
+	steps       int
+	deadline    time.Time
+	history     undoHistory
+	sandbox     sandboxState
+	hooks       hookState
 }
 
 // Init initializes an RPNCalc object
@@ -29,35 +61,71 @@ func (r *RPN) Init(maxStackDepth int) {
 	r.Frames = make([]Frame, 0, 16) // object allocated on the heap: object size 10240 exceeds maximum stack allocation size 256
 	r.maxStackDepth = maxStackDepth
 	r.functions = make(map[string]func(*RPN) error)
+	r.signatures = make(map[string]*Signature)
 	elog.Heap("alloc: /rpn/rpn.go:28: r.variables = []map[string]Frame{make(map[string]Frame)}")
 	r.variables = make(map[string][]Frame) // object allocated on the heap: escapes at line 28
-	r.conv = convert.Init()                // must come before initHelp()
+	r.locals = make(map[string]int)
+	r.macroCache = make(map[string]*Macro)
+	r.userCommands = make(map[string]userCommand)
+	r.modules = make(map[string]bool)
+	r.conv = convert.Init() // must come before initHelp()
 	r.initHelp()
 	r.registerCore()
 	r.Print = DefaultPrint
 	r.Interrupt = DefaultInterrupt
+	r.DebugPrompt = DefaultDebugPrompt
 	r.AngleUnit = POLAR_RAD_FRAME
 	r.TextWidth = 80
+	r.MaxCallDepth = DefaultMaxCallDepth
+	r.history.maxBytes = DefaultUndoMemory
+	r.sandbox.timeout = DefaultSandboxTimeout
 }
 
 func (r *RPN) registerCore() {
+	r.Register("redo", redo, CatStack, redoHelp)
 	r.Register("s.size", stackSize, CatStack, stackSizeHelp)
 	r.Register("s.snapshot", stackSnapshot, CatStack, stackSnapshotHelp)
+	r.Register("undo", undo, CatStack, undoHelp)
 	r.Register("v.clear", varClear, CatVariables, varClearHelp)
 	r.Register("v.clearall", varClearAll, CatVariables, varClearAllHelp)
 	r.Register("v.exists", varExists, CatVariables, varExistsHelp)
 	r.Register("v.list", listVariables, CatVariables, listVariablesHelp)
 	r.Register("v.snapshot", varSnapshot, CatVariables, varSnapshotHelp)
+	r.Register("conv.class", convClass, CatEng, convClassHelp)
+	r.Register("conv.unit", convUnit, CatEng, convUnitHelp)
+	r.Register("cur.info", curInfo, CatEng, curInfoHelp)
+	r.Register("cur.rate", curRate, CatEng, curRateHelp)
 	r.Register("deg", deg, CatEng, degHelp)
 	r.Register("getangle", getAngle, CatEng, getAngleHelp)
 	r.Register("grad", grad, CatEng, gradHelp)
 	r.Register("rad", rad, CatEng, radHelp)
 	r.Register("setangle", setAngle, CatEng, setAngleHelp)
+	r.Register("bp", bp, CatProg, bpHelp)
+	r.Register("debug", debug, CatProg, debugHelp)
+	r.Register("def", def, CatProg, defHelp)
+	r.Register("def.module", defModule, CatProg, defModuleHelp)
+	r.Register("err.cause", errCause, CatProg, errCauseHelp)
+	r.Register("err.kind", errKind, CatProg, errKindHelp)
+	r.Register("limit.depth", limitDepth, CatProg, limitDepthHelp)
+	r.Register("limit.steps", limitSteps, CatProg, limitStepsHelp)
+	r.Register("lint", lint, CatProg, lintHelp)
+	r.Register("sandbox", sandbox, CatProg, sandboxHelp)
+	r.Register("sandbox.global", sandboxGlobal, CatProg, sandboxGlobalHelp)
+	r.Register("sandbox.time", sandboxTime, CatProg, sandboxTimeHelp)
+	r.Register("timeout", timeout, CatProg, timeoutHelp)
+	r.Register("trace", trace, CatProg, traceHelp)
+	r.Register("undef", undef, CatProg, undefHelp)
+	r.Register("prof.report", profReport, CatStatus, profReportHelp)
+	r.Register("prof.start", profStart, CatStatus, profStartHelp)
+	r.Register("prof.stop", profStop, CatStatus, profStopHelp)
 }
 
 // Register adds a new function
 func (rpn *RPN) Register(name string, fn func(f *RPN) error, helpcat, helptxt string) {
 	rpn.functions[name] = fn
+	// a new function does not keep the signature of the one it replaces
+	delete(rpn.signatures, name)
+	rpn.funcGen++
 	cat := rpn.help[helpcat]
 	if cat == nil {
 		rpn.help[helpcat] = map[string]string{name: helptxt}
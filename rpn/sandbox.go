@@ -0,0 +1,235 @@
+package rpn
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultSandboxTimeout is how long a sandboxed script can run by default
+const DefaultSandboxTimeout = 10 * time.Second
+
+type sandboxState struct {
+	// number of nested sandbox calls
+	depth int
+	// true if everything runs in the sandbox
+	global bool
+	// maximum runtime of each sandboxed call, 0 for no limit
+	timeout time.Duration
+	// the directory sandboxed code can write files to, if any
+	dir string
+	// owned maps the variables that sandboxed code has written to the
+	// index of the first value that it owns.  Sandboxed code can only
+	// change those values.
+	owned map[string]int
+}
+
+// Sandboxed returns true if the running code is not trusted.  Commands
+// that can harm the system (shell commands, formatting, writing files
+// outside of the sandbox directory) should return ErrSandboxed.
+func (r *RPN) Sandboxed() bool {
+	return r.sandbox.global || (r.sandbox.depth > 0)
+}
+
+// RunSandboxed calls fn in the sandbox, stopping it with ErrTimeout if it
+// runs for longer than the sandbox timeout.
+func (r *RPN) RunSandboxed(fn func() error) error {
+	r.sandbox.depth++
+	defer func() { r.sandbox.depth-- }()
+	if r.sandbox.timeout > 0 {
+		deadline := time.Now().Add(r.sandbox.timeout)
+		prev := r.deadline
+		if prev.IsZero() || deadline.Before(prev) {
+			r.deadline = deadline
+		}
+		defer func() { r.deadline = prev }()
+	}
+	return fn()
+}
+
+// SetSandboxDir sets the directory that sandboxed code can write files
+// to.  An empty string means that it can not write files.
+func (r *RPN) SetSandboxDir(dir string) error {
+	if r.Sandboxed() {
+		return ErrSandboxed
+	}
+	if len(dir) > 0 {
+		if !filepath.IsAbs(dir) {
+			return ErrIllegalValue
+		}
+		dir = filepath.Clean(dir)
+	}
+	r.sandbox.dir = dir
+	return nil
+}
+
+// WritablePath returns the path to write a file to.  In the sandbox,
+// relative paths are relative to the sandbox directory and paths outside
+// of it are refused.
+func (r *RPN) WritablePath(path string) (string, error) {
+	if !r.Sandboxed() {
+		return path, nil
+	}
+	dir := r.sandbox.dir
+	if len(dir) == 0 {
+		return "", ErrSandboxed
+	}
+	if filepath.IsAbs(path) {
+		path = filepath.Clean(path)
+	} else {
+		path = filepath.Join(dir, path)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if (err != nil) || (rel == "..") || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrSandboxed
+	}
+	return path, nil
+}
+
+// checkWritableVariable returns an error if the code is sandboxed and is
+// not allowed to change the values of the variable key from index from
+// onwards.  Special variables such as .f1 and .init are run later without
+// the sandbox, as are macros in variables that were set outside of it.  So
+// sandboxed code can only change variables that it created and values that
+// it pushed.
+func (r *RPN) checkWritableVariable(key string, from int) error {
+	if !r.Sandboxed() {
+		// the variable is trusted from now on
+		delete(r.sandbox.owned, key)
+		return nil
+	}
+	if strings.HasPrefix(key, ".") {
+		return ErrSandboxed
+	}
+	if r.sandbox.global {
+		// nothing runs outside of the sandbox again
+		return nil
+	}
+	base, ok := r.sandbox.owned[key]
+	if !ok {
+		if len(r.variables[key]) > 0 {
+			return ErrSandboxed
+		}
+		r.ownVariable(key, 0)
+		return nil
+	}
+	if from < base {
+		return ErrSandboxed
+	}
+	return nil
+}
+
+// ownVariable records that sandboxed code owns the values of variable key
+// from index base onwards
+func (r *RPN) ownVariable(key string, base int) {
+	if r.sandbox.owned == nil {
+		r.sandbox.owned = make(map[string]int)
+	}
+	r.sandbox.owned[key] = base
+}
+
+// sandboxOwns returns true if the current value of variable key was set
+// by sandboxed code
+func (r *RPN) sandboxOwns(key string) bool {
+	base, ok := r.sandbox.owned[key]
+	return ok && (len(r.variables[key]) > base)
+}
+
+// pushLocal pushes f onto variable key for a value that is removed again
+// with truncateVariable, such as a macro parameter.  Sandboxed code owns
+// its locals, even when they hide a variable that it can not change.
+func (r *RPN) pushLocal(key string, f Frame) error {
+	vlist := r.variables[key]
+	if !r.Sandboxed() {
+		delete(r.sandbox.owned, key)
+	} else if strings.HasPrefix(key, ".") {
+		return ErrSandboxed
+	} else if base, ok := r.sandbox.owned[key]; !ok || (len(vlist) < base) {
+		r.ownVariable(key, len(vlist))
+	}
+	r.recordUndoVar(key)
+	r.variables[key] = append(vlist, f)
+	return nil
+}
+
+const sandboxHelp = "Pops a macro and runs it in the sandbox, for running " +
+	"scripts that you do not trust.  In the sandbox, shell commands and " +
+	"format are refused, files can only be written to the directory set " +
+	"with sandbox.dir, special (.) variables and variables set outside of " +
+	"the sandbox can not be changed, commands defined outside of the sandbox " +
+	"can not be redefined or removed, limits can not be raised and the macro " +
+	"is stopped if it runs for longer than sandbox.time seconds.  Commands " +
+	"defined with def and macros stored in variables by the sandbox always " +
+	"run in the sandbox.\n" +
+	"Examples:\n" +
+	"  {'script.rpn' source} sandbox\n" +
+	"  {'stats' import} sandbox\n" +
+	"See Also: sandbox.dir, sandbox.global, sandbox.time"
+
+func sandbox(r *RPN) error {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		return ErrExpectedAString
+	}
+	m, err := r.CompileMacro(f.UnsafeString())
+	if err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	return r.RunSandboxed(func() error {
+		return r.ExecCompiled(m)
+	})
+}
+
+const sandboxGlobalHelp = "Pops a boolean.  If true, everything runs in the " +
+	"sandbox, including commands that are typed in, and source and import " +
+	"are limited to sandbox.time seconds.  Once enabled, it can not be " +
+	"disabled without restarting.\n" +
+	"Example: true sandbox.global\n" +
+	"See Also: sandbox"
+
+func sandboxGlobal(r *RPN) error {
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	enable, err := f.Bool()
+	if err != nil {
+		return err
+	}
+	if !enable && r.Sandboxed() {
+		return ErrSandboxed
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	r.sandbox.global = enable
+	return nil
+}
+
+const sandboxTimeHelp = "Pops the number of seconds that each sandboxed call " +
+	"can run for.  0 means no limit.  The default is 10.  Can not be " +
+	"changed in the sandbox.\n" +
+	"Example: 60 sandbox.time\n" +
+	"See Also: sandbox, timeout"
+
+func sandboxTime(r *RPN) error {
+	if r.Sandboxed() {
+		return ErrSandboxed
+	}
+	f, err := r.PeekFrame(0)
+	if err != nil {
+		return err
+	}
+	secs, err := f.Real()
+	if err != nil {
+		return err
+	}
+	if secs < 0 {
+		return ErrIllegalValue
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	r.sandbox.timeout = time.Duration(secs * float64(time.Second))
+	return nil
+}
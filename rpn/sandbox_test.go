@@ -0,0 +1,171 @@
+package rpn
+
+import "testing"
+
+func TestSandbox(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Args: []string{"{1 x=}", "sandbox", "$x"},
+			Want: []string{"1"},
+		},
+		{
+			Args:    []string{"{1 .f1=}", "sandbox"},
+			Want:    []string{"1"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Args:    []string{"{1 .f1<}", "sandbox"},
+			Want:    []string{"1"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Args:    []string{"{10 limit.depth}", "sandbox"},
+			Want:    []string{"10"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Args:    []string{"{0 limit.steps}", "sandbox"},
+			Want:    []string{"0"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Args:    []string{"{60 sandbox.time}", "sandbox"},
+			Want:    []string{"60"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name: "sandbox ends",
+			Args: []string{"{1 x=}", "sandbox", "1", ".f1="},
+		},
+		{
+			Name:    "timeout",
+			Args:    []string{"1e-9", "sandbox.time", "{1 2}", "sandbox"},
+			WantErr: ErrTimeout,
+		},
+		{
+			Name: "no timeout",
+			Args: []string{"0", "sandbox.time", "{1 2}", "sandbox"},
+			Want: []string{"1", "2"},
+		},
+		{
+			Name:    "global",
+			Args:    []string{"true", "sandbox.global", "1", ".f1="},
+			Want:    []string{"1"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name:    "global can not be disabled",
+			Args:    []string{"true", "sandbox.global", "false", "sandbox.global"},
+			Want:    []string{"false"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name: "global off",
+			Args: []string{"false", "sandbox.global", "1", ".f1="},
+		},
+		{
+			Name:    "def in sandbox stays sandboxed",
+			Args:    []string{"{{1 .f1=} '' 'bad' def}", "sandbox", "bad"},
+			Want:    []string{"1"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name:    "can not redefine commands defined outside",
+			Args:    []string{"{1}", "''", "'one'", "def", "{{2} '' 'one' def}", "sandbox", "one"},
+			Want:    []string{"{2}", "''", "'one'"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name:    "can not undef commands defined outside",
+			Args:    []string{"{1}", "''", "'one'", "def", "{'one' undef}", "sandbox"},
+			Want:    []string{"'one'"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name: "can redefine its own commands",
+			Args: []string{"{{1} '' 'one' def {2} '' 'one' def one 'one' undef}", "sandbox"},
+			Want: []string{"2"},
+		},
+		{
+			Name: "global sandbox can redefine commands",
+			Args: []string{"{1}", "''", "'one'", "def", "true", "sandbox.global", "{2}", "''", "'one'", "def", "one"},
+			Want: []string{"2"},
+		},
+		{
+			Name:    "can not change variables set outside",
+			Args:    []string{"{1}", "x=", "{{2} x=}", "sandbox"},
+			Want:    []string{"{2}"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name:    "can not push to variables set outside",
+			Args:    []string{"1", "x=", "{2 x<}", "sandbox"},
+			Want:    []string{"2"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name:    "can not clear variables set outside",
+			Args:    []string{"1", "x=", "{x/}", "sandbox"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name: "can change its own variables",
+			Args: []string{"{1 x= 2 x< $x 3 x= x>}", "sandbox"},
+			Want: []string{"2", "3"},
+		},
+		{
+			Name: "parameters hide variables set outside",
+			Args: []string{"7", "x=", "{(x) 6 x= $x}", "f=", "{5 @f}", "sandbox", "$x"},
+			Want: []string{"6", "7"},
+		},
+		{
+			Name:    "macros stored in the sandbox run in it",
+			Args:    []string{"{{1 .f1=} m=}", "sandbox", "@m"},
+			Want:    []string{"1"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name:    "variables set outside are trusted",
+			Args:    []string{"{{1} m=}", "sandbox", "{1 .f1=}", "m=", "@m", "{{2} m=}", "sandbox"},
+			Want:    []string{"{2}"},
+			WantErr: ErrSandboxed,
+		},
+		{
+			Name: "global sandbox can change variables",
+			Args: []string{"1", "x=", "true", "sandbox.global", "2", "x=", "$x"},
+			Want: []string{"2"},
+		},
+		{
+			Name:    "not a string",
+			Args:    []string{"1", "sandbox"},
+			Want:    []string{"1"},
+			WantErr: ErrExpectedAString,
+		},
+	}
+	UnitTestExecAll(t, data, func(r *RPN) {
+		r.Register("true", func(r *RPN) error { return r.PushFrame(BoolFrame(true)) }, CatProg, "")
+		r.Register("false", func(r *RPN) error { return r.PushFrame(BoolFrame(false)) }, CatProg, "")
+	})
+}
+
+func TestSandboxDefSnapshot(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	if err := r.ExecSlice([]string{"{{1} 'One' 'one' def}", "sandbox", "{2}", "''", "'two'", "def"}); err != nil {
+		t.Fatal(err)
+	}
+	got := string(r.DefSnapshot(nil))
+	want := "{{1} 'One' 'one' def} sandbox\n{2} '' 'two' def\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSandboxErrorKind(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	err := r.ExecSlice([]string{"{1 .x=}", "sandbox"})
+	if got := ErrorKind(err); got != "sandbox" {
+		t.Errorf("ErrorKind()=%v, want sandbox", got)
+	}
+}
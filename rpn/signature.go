@@ -0,0 +1,137 @@
+package rpn
+
+import "strings"
+
+// ArgType is the expected type of a value in a Signature
+type ArgType uint8
+
+const (
+	AnyArg ArgType = iota
+	NumArg
+	IntArg
+	StrArg
+	BoolArg
+)
+
+var argTypeNames = []string{"any", "num", "int", "str", "bool"}
+
+// Arg is a value in a Signature
+type Arg struct {
+	Name string
+	Type ArgType
+}
+
+// Signature declares what a command pops and pushes.  It is written in
+// the usual stack effect notation with the head of the stack on the right
+// and an optional type after each name, e.g. ( a:num b:num -- c:num ).
+// Names without a type are any.  A leading ... means that the command
+// can pop or push more values than are listed.
+type Signature struct {
+	In      []Arg
+	Out     []Arg
+	MoreIn  bool
+	MoreOut bool
+}
+
+// ParseSignature parses a signature such as ( a:num b:num -- c:num )
+func ParseSignature(s string) (Signature, error) {
+	var sig Signature
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = s[1 : len(s)-1]
+	}
+	in, out, ok := strings.Cut(s, "--")
+	if !ok || strings.Contains(out, "--") {
+		return sig, ErrSyntax
+	}
+	var err error
+	sig.In, sig.MoreIn, err = parseArgs(in)
+	if err != nil {
+		return sig, err
+	}
+	sig.Out, sig.MoreOut, err = parseArgs(out)
+	return sig, err
+}
+
+func parseArgs(s string) ([]Arg, bool, error) {
+	var args []Arg
+	more := false
+	for i, field := range strings.Fields(s) {
+		if field == "..." {
+			if i > 0 {
+				return nil, false, ErrSyntax
+			}
+			more = true
+			continue
+		}
+		name, typ, hasType := strings.Cut(field, ":")
+		arg := Arg{Name: name}
+		if hasType {
+			found := false
+			for t, tname := range argTypeNames {
+				if typ == tname {
+					arg.Type = ArgType(t)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, false, ErrSyntax
+			}
+		}
+		if len(name) == 0 {
+			return nil, false, ErrSyntax
+		}
+		args = append(args, arg)
+	}
+	return args, more, nil
+}
+
+func (s Signature) String() string {
+	var sb strings.Builder
+	sb.WriteString("(")
+	writeArgs(&sb, s.In, s.MoreIn)
+	sb.WriteString(" --")
+	writeArgs(&sb, s.Out, s.MoreOut)
+	sb.WriteString(" )")
+	return sb.String()
+}
+
+func writeArgs(sb *strings.Builder, args []Arg, more bool) {
+	if more {
+		sb.WriteString(" ...")
+	}
+	for _, a := range args {
+		sb.WriteByte(' ')
+		sb.WriteString(a.Name)
+		if a.Type != AnyArg {
+			sb.WriteByte(':')
+			sb.WriteString(argTypeNames[a.Type])
+		}
+	}
+}
+
+// SetSignature declares what a registered command pops and pushes.  Help
+// shows the signature and lint uses it to estimate stack effects.  The
+// command still checks its own arguments when it is called.
+func (r *RPN) SetSignature(name, sig string) error {
+	if r.functions[name] == nil {
+		return ErrNotFound
+	}
+	s, err := ParseSignature(sig)
+	if err != nil {
+		return err
+	}
+	r.signatures[name] = &s
+	r.funcGen++
+	return nil
+}
+
+// Signature returns the signature of a command, if it has one
+func (r *RPN) Signature(name string) (Signature, bool) {
+	s := r.signatures[name]
+	if s == nil {
+		return Signature{}, false
+	}
+	return *s, true
+}
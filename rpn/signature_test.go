@@ -0,0 +1,79 @@
+package rpn
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseSignature(t *testing.T) {
+	data := []struct {
+		sig     string
+		want    string
+		wantErr error
+	}{
+		{sig: "( a:num b:num -- c:num )", want: "( a:num b:num -- c:num )"},
+		{sig: "a b:any -- b a", want: "( a b -- b a )"},
+		{sig: " -- ", want: "( -- )"},
+		{sig: "... n:int -- ... s:str b:bool", want: "( ... n:int -- ... s:str b:bool )"},
+		{sig: "a b", wantErr: ErrSyntax},
+		{sig: "a -- b -- c", wantErr: ErrSyntax},
+		{sig: "a ... -- b", wantErr: ErrSyntax},
+		{sig: "a:float -- b", wantErr: ErrSyntax},
+		{sig: ":num -- b", wantErr: ErrSyntax},
+	}
+	for _, d := range data {
+		sig, err := ParseSignature(d.sig)
+		if !errors.Is(err, d.wantErr) {
+			t.Errorf("ParseSignature(%q) err=%v, want %v", d.sig, err, d.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := sig.String(); got != d.want {
+			t.Errorf("ParseSignature(%q)=%q, want %q", d.sig, got, d.want)
+		}
+	}
+}
+
+func TestSetSignature(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	if err := r.SetSignature("nope", "a -- b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err=%v, want %v", err, ErrNotFound)
+	}
+	if err := r.SetSignature("s.size", "a:x -- b"); !errors.Is(err, ErrSyntax) {
+		t.Errorf("err=%v, want %v", err, ErrSyntax)
+	}
+	if err := r.ExecSlice([]string{"{x= $x $x}", "'Doubles'", "'twice'", "def"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetSignature("twice", "x -- x x"); err != nil {
+		t.Fatal(err)
+	}
+	if sig, ok := r.Signature("twice"); !ok || (sig.String() != "( x -- x x )") {
+		t.Errorf("Signature(twice)=%v %v", sig, ok)
+	}
+	var out strings.Builder
+	r.Print = func(msg string) { out.WriteString(msg) }
+	if err := r.Exec("twice?"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "( x -- x x )\nDoubles") {
+		t.Errorf("help=%q, want the signature before the help text", got)
+	}
+	if res := r.Lint("1 twice twice"); !res.EffectKnown || (res.Pops != 0) || (res.Pushes != 3) {
+		t.Errorf("Lint=%+v, want pops 0, pushes 3", res)
+	}
+	// redefining the command drops the signature
+	if err := r.ExecSlice([]string{"{}", "''", "'twice'", "def"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Signature("twice"); ok {
+		t.Error("redefined command kept its signature")
+	}
+	if err := r.ExecSlice([]string{"'twice'", "undef"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -9,6 +9,11 @@ func (r *RPN) StackLen() int {
 	return len(r.Frames)
 }
 
+// MaxStackDepth returns the maximum number of frames the stack can hold
+func (r *RPN) MaxStackDepth() int {
+	return r.maxStackDepth
+}
+
 func (r *RPN) PushFrame(f Frame) error {
 	if len(r.Frames) >= r.maxStackDepth {
 		return ErrStackFull
@@ -0,0 +1,184 @@
+package rpn
+
+import (
+	"errors"
+	"mattwach/rpngo/elog"
+	"mattwach/rpngo/parse"
+	"strconv"
+)
+
+// TraceFrame is one level of an ExecError's call stack
+type TraceFrame struct {
+	// Macro is the name of the variable the macro came from, or empty if
+	// the macro was not called by name
+	Macro string
+	// Index is the index of Token in the macro
+	Index int
+	Token string
+	// Context is the macro with Token marked, e.g. 1 ->foo<- 2
+	Context string
+}
+
+// ExecError is an error from executing a macro.  It records where the
+// error happened at each level of the call stack.
+type ExecError struct {
+	// Err is the root cause
+	Err error
+	// Trace is the call stack, innermost first
+	Trace []TraceFrame
+	// wrapped is the error from the level below, which is Err at the
+	// innermost level
+	wrapped error
+}
+
+// Error reports where the error happened at each level, outermost first,
+// e.g. 2 ->@f<- 3: 1 ->foo<-: syntax error
+func (e *ExecError) Error() string {
+	if len(e.Trace) == 0 {
+		return e.Err.Error()
+	}
+	return e.Trace[len(e.Trace)-1].Context + ": " + e.wrapped.Error()
+}
+
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// wrapExecError returns an error that adds a level to the call stack of
+// err.  context is the macro with the failing token marked.  Control flow
+// errors are returned unchanged.
+func (r *RPN) wrapExecError(err error, macro string, args []string, idx int, context string) error {
+	if _, ok := err.(parse.PassThroughError); ok {
+		return err
+	}
+	root := err
+	var trace []TraceFrame
+	if inner, ok := err.(*ExecError); ok {
+		root = inner.Err
+		// the full slice expression makes append copy, so inner is unchanged
+		trace = inner.Trace[:len(inner.Trace):len(inner.Trace)]
+	}
+	elog.Heap("alloc: rpn/trace.go:63: ee := &ExecError{...}")
+	ee := &ExecError{ // object allocated on the heap: escapes at line 63
+		Err: root,
+		Trace: append(trace, TraceFrame{
+			Macro:   macro,
+			Index:   idx,
+			Token:   args[idx],
+			Context: context,
+		}),
+		wrapped: err,
+	}
+	r.lastError = ee
+	return ee
+}
+
+// newExecError records an error that happened before any tokens could be
+// executed, such as a parse error.
+func (r *RPN) newExecError(err error) error {
+	elog.Heap("alloc: rpn/trace.go:70: r.lastError = &ExecError{Err: err}")
+	r.lastError = &ExecError{Err: err} // object allocated on the heap: escapes at line 70
+	return r.lastError
+}
+
+// errorKinds maps errors to the names returned by err.kind
+var errorKinds = []struct {
+	err  error
+	kind string
+}{
+	{ErrAssertion, "assert"},
+	{ErrCallDepth, "limit"},
+	{ErrDivideByZero, "divide_by_zero"},
+	{ErrExpectedABoolean, "expected_boolean"},
+	{ErrExpectedAComplexNumber, "expected_number"},
+	{ErrExpectedANumber, "expected_number"},
+	{ErrExpectedAPositiveNumber, "expected_number"},
+	{ErrExpectedAString, "expected_string"},
+	{ErrExpectedAnInteger, "expected_number"},
+	{ErrIllegalName, "illegal_name"},
+	{ErrIllegalValue, "illegal_value"},
+	{ErrInterrupted, "interrupted"},
+	{ErrNotEnoughStackFrames, "stack_empty"},
+	{ErrNotFound, "not_found"},
+	{ErrNotSupported, "not_supported"},
+	{ErrSandboxed, "sandbox"},
+	{ErrStackEmpty, "stack_empty"},
+	{ErrStackFull, "stack_full"},
+	{ErrSyntax, "syntax"},
+	{ErrTestsFailed, "assert"},
+	{ErrTimeout, "limit"},
+	{ErrTooManySteps, "limit"},
+	{parse.ErrUnterminatedBrace, "syntax"},
+	{parse.ErrUnterminatedDouble, "syntax"},
+	{parse.ErrUnterminatedSingleQuote, "syntax"},
+}
+
+// UserError is an error created by a script, e.g. with the error command
+type UserError string
+
+func (e UserError) Error() string {
+	return string(e)
+}
+
+// ErrorKind returns a short name for the type of err, such as syntax or
+// stack_empty.
+func ErrorKind(err error) string {
+	for _, ek := range errorKinds {
+		if errors.Is(err, ek.err) {
+			return ek.kind
+		}
+	}
+	var ue UserError
+	if errors.As(err, &ue) {
+		return "user"
+	}
+	return "other"
+}
+
+const traceHelp = "Prints the call stack of the last error\n" +
+	"See Also: err.cause, err.kind, try"
+
+func trace(r *RPN) error {
+	if r.lastError == nil {
+		r.Println("No errors")
+		return nil
+	}
+	r.Println(r.lastError.Err.Error())
+	for _, tf := range r.lastError.Trace {
+		name := tf.Macro
+		if len(name) == 0 {
+			name = "{}"
+		} else {
+			name = "@" + name
+		}
+		r.Println("  " + name + " " + strconv.Itoa(tf.Index) + ": " + tf.Context)
+	}
+	return nil
+}
+
+const errCauseHelp = "Pushes the root cause of the last error as a string, " +
+	"without any information about where it happened.\n" +
+	"Example: {$x 0 /} {0/ err.cause println} try\n" +
+	"See Also: err.kind, trace, try"
+
+func errCause(r *RPN) error {
+	if r.lastError == nil {
+		return ErrNotFound
+	}
+	return r.PushFrame(StringFrame(r.lastError.Err.Error(), STRING_SINGLEQ_FRAME))
+}
+
+const errKindHelp = "Pushes the kind of the last error as a string.  Kinds " +
+	"include assert, divide_by_zero, expected_boolean, expected_number, " +
+	"expected_string, illegal_name, illegal_value, interrupted, limit, not_found, " +
+	"not_supported, sandbox, stack_empty, stack_full, syntax, user (from the error " +
+	"command) and other.\n" +
+	"Example: {@f} {0/ err.kind 'stack_empty' = {'need more values' println} if} try\n" +
+	"See Also: err.cause, trace, try"
+
+func errKind(r *RPN) error {
+	if r.lastError == nil {
+		return ErrNotFound
+	}
+	return r.PushFrame(StringFrame(ErrorKind(r.lastError.Err), STRING_SINGLEQ_FRAME))
+}
@@ -0,0 +1,126 @@
+package rpn
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecErrorTrace(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	err := r.ExecSlice([]string{"{1 foo}", "f=", "{2 @f 3}", "g=", "@g"})
+	if !errors.Is(err, ErrSyntax) {
+		t.Fatalf("err=%v, want %v", err, ErrSyntax)
+	}
+	var ee *ExecError
+	if !errors.As(err, &ee) {
+		t.Fatalf("err=%T, want *ExecError", err)
+	}
+	want := []TraceFrame{
+		{Macro: "f", Index: 1, Token: "foo", Context: "1 ->foo<-"},
+		{Macro: "g", Index: 1, Token: "@f", Context: "2 ->@f<- 3"},
+		{Macro: "", Index: 4, Token: "@g", Context: "exec {1 foo} f= {2 @f 3} g= ->@g<-"},
+	}
+	if !reflect.DeepEqual(ee.Trace, want) {
+		t.Errorf("trace=%+v, want %+v", ee.Trace, want)
+	}
+	wantMsg := "exec {1 foo} f= {2 @f 3} g= ->@g<-: 2 ->@f<- 3: 1 ->foo<-: " + ErrSyntax.Error()
+	if got := err.Error(); got != wantMsg {
+		t.Errorf("Error()=%q, want %q", got, wantMsg)
+	}
+	if r.lastError != ee {
+		t.Error("lastError was not set")
+	}
+}
+
+func TestWrapExecErrorCopies(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	inner := r.wrapExecError(ErrSyntax, "f", []string{"1", "foo"}, 1, "1 ->foo<-")
+	outer := r.wrapExecError(inner, "g", []string{"@f"}, 0, "->@f<-")
+	if got := inner.Error(); got != "1 ->foo<-: "+ErrSyntax.Error() {
+		t.Errorf("inner Error()=%q", got)
+	}
+	if got := len(inner.(*ExecError).Trace); got != 1 {
+		t.Errorf("inner trace has %d frames, want 1", got)
+	}
+	if got := outer.Error(); got != "->@f<-: 1 ->foo<-: "+ErrSyntax.Error() {
+		t.Errorf("outer Error()=%q", got)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	var out strings.Builder
+	r.Print = func(msg string) { out.WriteString(msg) }
+	if err := r.Exec("trace"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "No errors\n" {
+		t.Errorf("got %q", out.String())
+	}
+	out.Reset()
+	r.ExecSlice([]string{"{$x}", "f=", "{@f}", "g=", "@g"})
+	if err := r.Exec("trace"); err != nil {
+		t.Fatal(err)
+	}
+	want := "not found\n" +
+		"  @f 0: ->$x<-\n" +
+		"  @g 0: ->@f<-\n" +
+		"  {} 4: exec {$x} f= {@f} g= ->@g<-\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestErrCauseAndKind(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Args:    []string{"err.kind"},
+			WantErr: ErrNotFound,
+		},
+		{
+			Args:    []string{"err.cause"},
+			WantErr: ErrNotFound,
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+
+	var r RPN
+	r.Init(256)
+	r.ExecSlice([]string{"{s.size foo}", "f=", "@f"})
+	r.Clear()
+	if err := r.ExecSlice([]string{"err.cause", "err.kind"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"'" + ErrSyntax.Error() + "'", "'syntax'"}
+	var got []string
+	for _, f := range r.Frames {
+		got = append(got, f.String(true))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	data := []struct {
+		err  error
+		want string
+	}{
+		{ErrStackEmpty, "stack_empty"},
+		{ErrNotEnoughStackFrames, "stack_empty"},
+		{&ExecError{Err: ErrDivideByZero}, "divide_by_zero"},
+		{UserError("oops"), "user"},
+		{&ExecError{Err: UserError("oops")}, "user"},
+		{errors.New("something"), "other"},
+	}
+	for _, d := range data {
+		if got := ErrorKind(d.err); got != d.want {
+			t.Errorf("ErrorKind(%v)=%q, want %q", d.err, got, d.want)
+		}
+	}
+}
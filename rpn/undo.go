@@ -0,0 +1,239 @@
+package rpn
+
+import "mattwach/rpngo/elog"
+
+// DefaultUndoMemory is the default number of bytes that the undo history
+// can use.  It is kept small so that it fits comfortably on a RP2040.
+const DefaultUndoMemory = 4096
+
+// approximate sizes used to keep the undo history under its memory limit
+const (
+	undoStepBytes  = 32
+	undoFrameBytes = 48
+	undoVarBytes   = 32
+)
+
+// undoStep holds the state that was replaced by an input line.  Only the
+// variables that changed are recorded.  A nil vars entry means the
+// variable did not exist.
+type undoStep struct {
+	hasFrames bool
+	frames    []Frame
+	vars      map[string][]Frame
+	bytes     int
+}
+
+type undoHistory struct {
+	// maxBytes is the memory limit, 0 disables undo
+	maxBytes int
+	undo     []undoStep
+	redo     []undoStep
+	bytes    int
+	// state captured by BeginUndoStep.  vars holds the old values of the
+	// variables that changed since, nil if the variable did not exist.
+	started bool
+	frames  []Frame
+	vars    map[string][]Frame
+	// true if undo or redo ran since BeginUndoStep
+	replayed bool
+}
+
+// BeginUndoStep records the current stack so that the changes made before
+// EndUndoStep can be undone.  Variables are recorded by recordUndoVar when
+// they are first changed.  The input window calls
+// these around each line that is entered.
+func (r *RPN) BeginUndoStep() {
+	h := &r.history
+	h.started = false
+	h.replayed = false
+	if h.maxBytes == 0 {
+		return
+	}
+	h.started = true
+	h.frames = append(h.frames[:0], r.Frames...)
+	if h.vars == nil {
+		elog.Heap("alloc: rpn/undo.go:53: h.vars = make(map[string][]Frame)")
+		h.vars = make(map[string][]Frame) // object allocated on the heap: escapes at line 53
+	}
+	for name := range h.vars {
+		delete(h.vars, name)
+	}
+}
+
+// recordUndoVar saves the values of variable key the first time it is
+// changed after BeginUndoStep.
+func (r *RPN) recordUndoVar(key string) {
+	h := &r.history
+	if !h.started || h.replayed {
+		return
+	}
+	if _, ok := h.vars[key]; ok {
+		return
+	}
+	h.vars[key] = copyFrames(r.variables[key])
+}
+
+// EndUndoStep adds the changes made since BeginUndoStep to the undo
+// history.
+func (r *RPN) EndUndoStep() {
+	h := &r.history
+	if !h.started || h.replayed {
+		return
+	}
+	h.started = false
+	var step undoStep
+	if !equalFrames(h.frames, r.Frames) {
+		step.hasFrames = true
+		step.frames = copyFrames(h.frames)
+	}
+	for name, vals := range h.vars {
+		if !equalFrames(vals, r.variables[name]) {
+			step.setVar(name, vals)
+		}
+	}
+	if !step.hasFrames && (step.vars == nil) {
+		return
+	}
+	step.bytes = step.size()
+	h.clearRedo()
+	h.undo = append(h.undo, step)
+	h.bytes += step.bytes
+	h.trim()
+}
+
+// SetUndoMemory sets the number of bytes the undo history can use.  0
+// disables undo and frees the history.
+func (r *RPN) SetUndoMemory(n int) error {
+	if n < 0 {
+		return ErrExpectedAPositiveNumber
+	}
+	h := &r.history
+	h.maxBytes = n
+	if n == 0 {
+		*h = undoHistory{}
+		return nil
+	}
+	h.trim()
+	return nil
+}
+
+// UndoMemory returns the number of bytes the undo history can use
+func (r *RPN) UndoMemory() int {
+	return r.history.maxBytes
+}
+
+const undoHelp = "Reverts the stack and variables to the way they were " +
+	"before the last line was entered.  Can be repeated to go further " +
+	"back, up to the memory limit set by the input window undomem " +
+	"property.  The last line can always be undone.\n" +
+	"Example: 1 2 3 d undo # the stack is back to 1 2 3\n" +
+	"See Also: redo"
+
+func undo(r *RPN) error {
+	h := &r.history
+	if len(h.undo) == 0 {
+		return ErrNothingToUndo
+	}
+	step := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, r.applyUndoStep(step))
+	h.replayed = true
+	return nil
+}
+
+const redoHelp = "Reapplies the changes that were reverted by undo.\n" +
+	"Example: 1 2 3 d undo redo # the stack is empty again\n" +
+	"See Also: undo"
+
+func redo(r *RPN) error {
+	h := &r.history
+	if len(h.redo) == 0 {
+		return ErrNothingToRedo
+	}
+	step := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, r.applyUndoStep(step))
+	h.replayed = true
+	return nil
+}
+
+// applyUndoStep restores the state in step and returns a step that
+// restores the state that was replaced.
+func (r *RPN) applyUndoStep(step undoStep) undoStep {
+	var inverse undoStep
+	if step.hasFrames {
+		inverse.hasFrames = true
+		inverse.frames = copyFrames(r.Frames)
+		r.Frames = append(r.Frames[:0], step.frames...)
+	}
+	for name, vals := range step.vars {
+		inverse.setVar(name, copyFrames(r.variables[name]))
+		if vals == nil {
+			delete(r.variables, name)
+		} else {
+			r.variables[name] = copyFrames(vals)
+		}
+	}
+	inverse.bytes = inverse.size()
+	r.history.bytes += inverse.bytes - step.bytes
+	return inverse
+}
+
+func (s *undoStep) setVar(name string, vals []Frame) {
+	if s.vars == nil {
+		elog.Heap("alloc: rpn/undo.go:177: s.vars = make(map[string][]Frame)")
+		s.vars = make(map[string][]Frame) // object allocated on the heap: escapes at line 177
+	}
+	s.vars[name] = vals
+}
+
+func (s *undoStep) size() int {
+	n := undoStepBytes + len(s.frames)*undoFrameBytes
+	for name, vals := range s.vars {
+		n += undoVarBytes + len(name) + len(vals)*undoFrameBytes
+	}
+	return n
+}
+
+func (h *undoHistory) clearRedo() {
+	for _, step := range h.redo {
+		h.bytes -= step.bytes
+	}
+	h.redo = h.redo[:0]
+}
+
+// trim drops the oldest steps until the history fits in maxBytes.  The
+// most recent undo step is always kept, even if it is larger than
+// maxBytes on its own, so that the last line can be undone.
+func (h *undoHistory) trim() {
+	for (h.bytes > h.maxBytes) && (len(h.undo) > 1) {
+		h.bytes -= h.undo[0].bytes
+		h.undo = h.undo[1:]
+	}
+	for (h.bytes > h.maxBytes) && (len(h.redo) > 0) {
+		h.bytes -= h.redo[0].bytes
+		h.redo = h.redo[1:]
+	}
+}
+
+func copyFrames(frames []Frame) []Frame {
+	if frames == nil {
+		return nil
+	}
+	elog.Heap("alloc: rpn/undo.go:214: c := make([]Frame, len(frames))")
+	c := make([]Frame, len(frames)) // object allocated on the heap: size is not constant
+	copy(c, frames)
+	return c
+}
+
+func equalFrames(a, b []Frame) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
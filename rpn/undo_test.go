@@ -0,0 +1,171 @@
+package rpn
+
+import (
+	"errors"
+	"mattwach/rpngo/parse"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// execLines runs each line as a single undo step, like the input window,
+// and returns the error from the last line.
+func execLines(r *RPN, lines []string) error {
+	var err error
+	for _, line := range lines {
+		r.BeginUndoStep()
+		err = parse.Fields(line, r.Exec)
+		r.EndUndoStep()
+	}
+	return err
+}
+
+func TestUndo(t *testing.T) {
+	data := []struct {
+		name     string
+		lines    []string
+		memory   int
+		want     []string
+		wantVars map[string]string
+		wantErr  error
+	}{
+		{
+			name:  "drop",
+			lines: []string{"1 2 3", "0/ 0/ 0/", "undo"},
+			want:  []string{"1", "2", "3"},
+		},
+		{
+			name:  "multiple",
+			lines: []string{"1", "2", "3", "undo", "undo"},
+			want:  []string{"1"},
+		},
+		{
+			name:  "redo",
+			lines: []string{"1 2 3", "0/ 0/ 0/", "undo", "redo"},
+		},
+		{
+			name:  "redo after undo twice",
+			lines: []string{"1", "2", "undo", "undo", "redo"},
+			want:  []string{"1"},
+		},
+		{
+			name:    "new line clears redo",
+			lines:   []string{"1", "2", "undo", "5", "redo"},
+			want:    []string{"1", "5"},
+			wantErr: ErrNothingToRedo,
+		},
+		{
+			name:     "variables",
+			lines:    []string{"1 x=", "2 x=", "3 y=", "undo", "undo"},
+			wantVars: map[string]string{"x": "1"},
+		},
+		{
+			name:     "variable stacks",
+			lines:    []string{"1 x=", "2 x<", "undo"},
+			wantVars: map[string]string{"x": "1"},
+		},
+		{
+			name:     "variables redo",
+			lines:    []string{"1 x=", "x>", "undo", "redo"},
+			want:     []string{"1"},
+			wantVars: map[string]string{},
+		},
+		{
+			name:     "clear all",
+			lines:    []string{"1 x= 2 .y=", "v.clearall", "undo"},
+			wantVars: map[string]string{"x": "1", ".y": "2"},
+		},
+		{
+			name:     "macro parameters",
+			lines:    []string{"{(a) $a} m=", "5 @m", "undo"},
+			wantVars: map[string]string{"m": "{(a) $a}"},
+		},
+		{
+			name:    "nothing to undo",
+			lines:   []string{"undo"},
+			wantErr: ErrNothingToUndo,
+		},
+		{
+			name:    "failed line",
+			lines:   []string{"1", "2 foo"},
+			want:    []string{"1", "2"},
+			wantErr: ErrSyntax,
+		},
+		{
+			name:  "failed line undo",
+			lines: []string{"1", "2 3 0/ foo", "undo"},
+			want:  []string{"1"},
+		},
+		{
+			name:    "disabled",
+			memory:  -1,
+			lines:   []string{"1", "2", "undo"},
+			want:    []string{"1", "2"},
+			wantErr: ErrNothingToUndo,
+		},
+		{
+			name: "memory limit",
+			// enough for the last two steps
+			memory:  2*undoStepBytes + 3*undoFrameBytes,
+			lines:   []string{"1", "2", "3", "undo", "undo", "undo"},
+			want:    []string{"1"},
+			wantErr: ErrNothingToUndo,
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			var r RPN
+			r.Init(256)
+			if d.memory != 0 {
+				// -1 disables undo
+				r.SetUndoMemory(max(d.memory, 0))
+			}
+			err := execLines(&r, d.lines)
+			if !errors.Is(err, d.wantErr) {
+				t.Fatalf("err=%v, want %v", err, d.wantErr)
+			}
+			var got []string
+			for _, f := range r.Frames {
+				got = append(got, f.String(true))
+			}
+			if !reflect.DeepEqual(got, d.want) {
+				t.Errorf("got %v, want %v", got, d.want)
+			}
+			if d.wantVars != nil {
+				gotVars := make(map[string]string)
+				for name, vals := range r.variables {
+					gotVars[name] = vals[len(vals)-1].String(true)
+				}
+				if !reflect.DeepEqual(gotVars, d.wantVars) {
+					t.Errorf("got vars %v, want %v", gotVars, d.wantVars)
+				}
+			}
+		})
+	}
+}
+
+func TestUndoStepLargerThanLimit(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	// more values than fit in DefaultUndoMemory
+	n := DefaultUndoMemory/undoFrameBytes + 10
+	var want []string
+	for i := 0; i < n; i++ {
+		want = append(want, strconv.Itoa(i))
+	}
+	if err := execLines(&r, []string{"1 x=", strings.Join(want, " "), "x==", "undo"}); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range r.Frames {
+		got = append(got, f.String(true))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// the older steps were dropped to make room
+	if err := execLines(&r, []string{"undo"}); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("err=%v, want %v", err, ErrNothingToUndo)
+	}
+}
@@ -0,0 +1,130 @@
+package rpn
+
+import (
+	"mattwach/rpngo/convert"
+	"strconv"
+	"strings"
+)
+
+const convClassHelp = "Defines a new unit class for conversions.  Pops a string of\n" +
+	"base unit names, then the class name.  The class name can not be the\n" +
+	"name of an existing dimension, such as length.\n" +
+	"Example: 'ADC' 'count counts' conv.class\n" +
+	"See Also: conv.unit, conversions"
+
+func convClass(r *RPN) error {
+	if len(r.Frames) < 2 {
+		return ErrNotEnoughStackFrames
+	}
+	namesf := r.Frames[len(r.Frames)-1]
+	classf := r.Frames[len(r.Frames)-2]
+	if !namesf.IsString() || !classf.IsString() {
+		return ErrExpectedAString
+	}
+	if err := r.conv.AddClass(classf.UnsafeString(), strings.Fields(namesf.UnsafeString())); err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-2]
+	r.refreshConversionHelp()
+	return nil
+}
+
+const convUnitHelp = "Defines a new conversion unit in terms of an existing unit\n" +
+	"where base = (value + offset) * scale.  Pops a string of names,\n" +
+	"the base unit name, offset, and scale.\n" +
+	"Examples:\n" +
+	"  0.001 0 'in' 'mil mils' conv.unit\n" +
+	"  1 -459.67 'f' 'rankine' conv.unit\n" +
+	"See Also: conv.class, conversions"
+
+func convUnit(r *RPN) error {
+	if len(r.Frames) < 4 {
+		return ErrNotEnoughStackFrames
+	}
+	namesf := r.Frames[len(r.Frames)-1]
+	basef := r.Frames[len(r.Frames)-2]
+	if !namesf.IsString() || !basef.IsString() {
+		return ErrExpectedAString
+	}
+	offsetf := r.Frames[len(r.Frames)-3]
+	offset, err := offsetf.Real()
+	if err != nil {
+		return err
+	}
+	scalef := r.Frames[len(r.Frames)-4]
+	scale, err := scalef.Real()
+	if err != nil {
+		return err
+	}
+	err = r.conv.AddUnit(basef.UnsafeString(), scale, offset, strings.Fields(namesf.UnsafeString()))
+	if err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-4]
+	r.refreshConversionHelp()
+	return nil
+}
+
+const curRateHelp = "Sets the conversion rate of a single currency, relative to\n" +
+	"a base currency.  Pops the currency code, then the number of units\n" +
+	"of that currency per unit of the base currency.\n" +
+	"Example: 1 'usd' cur.rate 0.92 'eur' cur.rate 100 usd>eur\n" +
+	"See Also: cur.info, cur.load"
+
+func curRate(r *RPN) error {
+	if len(r.Frames) < 2 {
+		return ErrNotEnoughStackFrames
+	}
+	codef := r.Frames[len(r.Frames)-1]
+	if !codef.IsString() {
+		return ErrExpectedAString
+	}
+	ratef := r.Frames[len(r.Frames)-2]
+	rate, err := ratef.Real()
+	if err != nil {
+		return err
+	}
+	cr := convert.CurrencyRate{Code: codef.UnsafeString(), Rate: rate}
+	// there is no date for a single rate, and the Pico has no clock to
+	// provide one
+	if err := r.conv.SetCurrencyRate(cr, ""); err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-2]
+	r.refreshConversionHelp()
+	return nil
+}
+
+const curInfoHelp = "Prints the number of currency rates and when they were " +
+	"last updated, as given by the updated line of the file loaded with " +
+	"cur.load.  The date is dropped when a rate is changed with cur.rate."
+
+func curInfo(r *RPN) error {
+	updated, count := r.conv.CurrencyInfo()
+	if count == 0 {
+		r.Println("No currency rates are loaded")
+		return nil
+	}
+	msg := strconv.Itoa(count) + " currencies"
+	if len(updated) > 0 {
+		msg += ", updated " + updated
+	}
+	r.Println(msg)
+	return nil
+}
+
+// SetCurrencyRates replaces all currency conversion rates.  updated is the
+// date of the rates, or empty if it is not known.
+func (r *RPN) SetCurrencyRates(rates []convert.CurrencyRate, updated string) error {
+	if err := r.conv.SetCurrencyRates(rates, updated); err != nil {
+		return err
+	}
+	r.refreshConversionHelp()
+	return nil
+}
+
+// refreshConversionHelp regenerates the conversions? help so that units
+// defined at runtime are listed.
+func (r *RPN) refreshConversionHelp() {
+	r.help[CatConcepts]["conversions"] = r.conv.Help()
+}
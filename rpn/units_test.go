@@ -0,0 +1,150 @@
+package rpn
+
+import (
+	"mattwach/rpngo/convert"
+	"strings"
+	"testing"
+)
+
+func TestConvUnit(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Name:    "empty",
+			Args:    []string{"conv.unit"},
+			WantErr: ErrNotEnoughStackFrames,
+		},
+		{
+			Name:    "not a string",
+			Args:    []string{"0.001", "0", "'in'", "5", "conv.unit"},
+			Want:    []string{"0.001", "0", "'in'", "5"},
+			WantErr: ErrExpectedAString,
+		},
+		{
+			Name:    "not a number",
+			Args:    []string{"0.001", "'x'", "'in'", "'mil'", "conv.unit"},
+			Want:    []string{"0.001", "'x'", "'in'", "'mil'"},
+			WantErr: ErrExpectedANumber,
+		},
+		{
+			Name: "mils",
+			Args: []string{"0.001", "0", "'in'", "'mil mils'", "conv.unit", "2", "mils>in"},
+			Want: []string{"0.002 `in"},
+		},
+		{
+			Name: "offset",
+			Args: []string{"1", "-459.67", "'f'", "'rankine'", "conv.unit", "491.67", "rankine>c"},
+			Want: []string{"0 `c"},
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+}
+
+func TestConvClass(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Name:    "empty",
+			Args:    []string{"'ADC'", "conv.class"},
+			Want:    []string{"'ADC'"},
+			WantErr: ErrNotEnoughStackFrames,
+		},
+		{
+			Name:    "not a string",
+			Args:    []string{"1", "'count'", "conv.class"},
+			Want:    []string{"1", "'count'"},
+			WantErr: ErrExpectedAString,
+		},
+		{
+			Name: "new class",
+			Args: []string{
+				"'ADC'", "'count counts'", "conv.class",
+				"4096", "0", "'counts'", "'fullscale'", "conv.unit",
+				"0.25", "fullscale>counts"},
+			Want: []string{"1024 `counts"},
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+}
+
+func TestConvClassHelp(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	if err := r.ExecSlice([]string{"'ADC'", "'count counts'", "conv.class"}); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	var got string
+	r.Print = func(msg string) {
+		got = got + msg
+	}
+	if err := r.Exec("conversions?"); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if !strings.Contains(got, "ADC:") || !strings.Contains(got, "counts") {
+		t.Errorf("conversions? help does not list ADC class: %v", got)
+	}
+}
+
+func TestCurRate(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Name:    "empty",
+			Args:    []string{"'usd'", "cur.rate"},
+			Want:    []string{"'usd'"},
+			WantErr: ErrNotEnoughStackFrames,
+		},
+		{
+			Name:    "not a string",
+			Args:    []string{"1", "2", "cur.rate"},
+			Want:    []string{"1", "2"},
+			WantErr: ErrExpectedAString,
+		},
+		{
+			Name:    "not a number",
+			Args:    []string{"'x'", "'usd'", "cur.rate"},
+			Want:    []string{"'x'", "'usd'"},
+			WantErr: ErrExpectedANumber,
+		},
+		{
+			Name: "convert",
+			Args: []string{"1", "'usd'", "cur.rate", "0.8", "'eur'", "cur.rate", "100", "usd>eur"},
+			Want: []string{"80 `eur"},
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+}
+
+func TestCurInfo(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	var got string
+	r.Print = func(msg string) {
+		got = got + msg
+	}
+	if err := r.Exec("cur.info"); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if got != "No currency rates are loaded\n" {
+		t.Errorf("got %v", got)
+	}
+	err := r.SetCurrencyRates([]convert.CurrencyRate{{Code: "usd", Rate: 1}, {Code: "eur", Rate: 0.8}}, "2026-10-01")
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	got = ""
+	if err := r.Exec("cur.info"); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if got != "2 currencies, updated 2026-10-01\n" {
+		t.Errorf("got %v", got)
+	}
+	// a single rate has no date
+	if err := r.ExecSlice([]string{"0.7", "'gbp'", "cur.rate"}); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	got = ""
+	if err := r.Exec("cur.info"); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if got != "3 currencies\n" {
+		t.Errorf("got %v", got)
+	}
+}
@@ -0,0 +1,200 @@
+package rpn
+
+import "sort"
+
+// userCommand is a macro registered as a command with def
+type userCommand struct {
+	macro string
+	help  string
+	// the name the command was defined with and the module it was defined
+	// in, if any.  The command is registered as namespace.name.
+	name      string
+	namespace string
+	// true if the command was defined in the sandbox
+	sandboxed bool
+}
+
+const defHelp = "Defines a new command from a macro.  Pops the command name, " +
+	"help text, then the macro.  The command can be called without @, " +
+	"is listed with ? under " + CatUser + " and is saved by snapshot.  " +
+	"Commands defined with def can be redefined but built-in commands can not.\n" +
+	"Example: {(x) $x sq $x *} 'Cubes a value' 'cube' def 3 cube\n" +
+	"See Also: undef, macros"
+
+func def(r *RPN) error {
+	if len(r.Frames) < 3 {
+		return ErrNotEnoughStackFrames
+	}
+	namef := r.Frames[len(r.Frames)-1]
+	helpf := r.Frames[len(r.Frames)-2]
+	macrof := r.Frames[len(r.Frames)-3]
+	if !namef.IsString() || !helpf.IsString() || !macrof.IsString() {
+		return ErrExpectedAString
+	}
+	name := namef.UnsafeString()
+	if err := r.DefineCommand(name, macrof.UnsafeString(), helpf.UnsafeString()); err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-3]
+	return nil
+}
+
+// DefineCommand registers macro as a command called name in the user
+// category.  When called from a module, name is prefixed with the module
+// name and the command runs in the module's namespace.
+func (r *RPN) DefineCommand(name, macro, help string) error {
+	if err := checkVariableName(name); err != nil {
+		return err
+	}
+	bare := name
+	name = r.scopedName(name)
+	if _, ok := r.userCommands[name]; !ok && (r.functions[name] != nil) {
+		// not allowed to replace built-in commands
+		return ErrIllegalName
+	}
+	if err := r.checkWritableCommand(name); err != nil {
+		return err
+	}
+	if _, err := r.CompileMacro(macro); err != nil {
+		return err
+	}
+	ns := r.namespace
+	sandboxed := r.Sandboxed()
+	r.userCommands[name] = userCommand{macro: macro, help: help, name: bare, namespace: ns, sandboxed: sandboxed}
+	r.Register(name, func(r *RPN) error {
+		m, err := r.CompileMacro(macro)
+		if err != nil {
+			return r.newExecError(err)
+		}
+		saved := r.setNamespace(ns)
+		defer r.setNamespace(saved)
+		if sandboxed {
+			return r.RunSandboxed(func() error {
+				return r.execCompiled(m, name)
+			})
+		}
+		return r.execCompiled(m, name)
+	}, CatUser, help)
+	return nil
+}
+
+const defModuleHelp = "Defines a new command in a module.  Pops the module name, " +
+	"then the same arguments as def.  The command is called as module.name " +
+	"and runs in the module's namespace, as if def was called by the module.  " +
+	"The module is marked as imported.  snapshot uses this to save commands " +
+	"that modules define.\n" +
+	"Example: {(r) $r sq const.pi *} 'Area of a circle' 'carea' 'geom' def.module\n" +
+	"See Also: def, import"
+
+func defModule(r *RPN) error {
+	if len(r.Frames) < 4 {
+		return ErrNotEnoughStackFrames
+	}
+	nsf := r.Frames[len(r.Frames)-1]
+	if !nsf.IsString() {
+		return ErrExpectedAString
+	}
+	ns := nsf.UnsafeString()
+	if err := checkModuleName(ns); err != nil {
+		return err
+	}
+	r.Frames = r.Frames[:len(r.Frames)-1]
+	saved := r.setNamespace(ns)
+	err := def(r)
+	r.setNamespace(saved)
+	if err != nil {
+		r.Frames = append(r.Frames, nsf)
+		return err
+	}
+	r.modules[ns] = true
+	return nil
+}
+
+const undefHelp = "Pops a name and removes the command defined with def.  " +
+	"Inside of a module, the name is relative to the module.\n" +
+	"Example: 'cube' undef\n" +
+	"See Also: def"
+
+func undef(r *RPN) error {
+	f, err := r.PopFrame()
+	if err != nil {
+		return err
+	}
+	if !f.IsString() {
+		r.PushFrame(f)
+		return ErrExpectedAString
+	}
+	name := r.scopedName(f.UnsafeString())
+	if _, ok := r.userCommands[name]; !ok {
+		r.PushFrame(f)
+		return ErrNotFound
+	}
+	if err := r.checkWritableCommand(name); err != nil {
+		r.PushFrame(f)
+		return err
+	}
+	delete(r.userCommands, name)
+	delete(r.functions, name)
+	delete(r.signatures, name)
+	delete(r.help[CatUser], name)
+	if len(r.help[CatUser]) == 0 {
+		delete(r.help, CatUser)
+	}
+	r.funcGen++
+	return nil
+}
+
+// checkWritableCommand returns an error if the code is sandboxed and name
+// is a command that was defined outside of the sandbox.  Those commands
+// run without the sandbox, so sandboxed code can not replace or remove
+// them.
+func (r *RPN) checkWritableCommand(name string) error {
+	if !r.Sandboxed() || r.sandbox.global {
+		return nil
+	}
+	if uc, ok := r.userCommands[name]; ok && !uc.sandboxed {
+		return ErrSandboxed
+	}
+	return nil
+}
+
+// DefSnapshot appends def commands that recreate all user commands
+func (r *RPN) DefSnapshot(buff []byte) []byte {
+	names := make([]string, 0, len(r.userCommands))
+	for name := range r.userCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		uc := r.userCommands[name]
+		if uc.sandboxed {
+			// define it in the sandbox again so that it stays there
+			def := appendDef(nil, uc)
+			buff = appendString(buff, string(def), STRING_BRACE_FRAME)
+			buff = append(buff, []byte(" sandbox\n")...)
+			continue
+		}
+		buff = appendDef(buff, uc)
+		buff = append(buff, '\n')
+	}
+	return buff
+}
+
+func appendDef(buff []byte, uc userCommand) []byte {
+	buff = appendString(buff, uc.macro, STRING_BRACE_FRAME)
+	buff = append(buff, ' ')
+	buff = appendString(buff, uc.help, STRING_SINGLEQ_FRAME)
+	buff = append(buff, ' ')
+	buff = appendString(buff, uc.name, STRING_SINGLEQ_FRAME)
+	if len(uc.namespace) == 0 {
+		return append(buff, []byte(" def")...)
+	}
+	buff = append(buff, ' ')
+	buff = appendString(buff, uc.namespace, STRING_SINGLEQ_FRAME)
+	return append(buff, []byte(" def.module")...)
+}
+
+func appendString(buff []byte, s string, t FrameType) []byte {
+	f := StringFrame(s, t)
+	return append(buff, []byte(f.String(true))...)
+}
@@ -0,0 +1,107 @@
+package rpn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDef(t *testing.T) {
+	data := []UnitTestExecData{
+		{
+			Args: []string{"{(x) $x $x}", "'Duplicates x'", "'twice'", "def", "3", "twice"},
+			Want: []string{"3", "3"},
+		},
+		{
+			Name: "redefine",
+			Args: []string{"{1}", "''", "'one'", "def", "{2}", "''", "'one'", "def", "one"},
+			Want: []string{"2"},
+		},
+		{
+			Name: "compiled macro sees redefinition",
+			Args: []string{"{1}", "''", "'one'", "def", "{one}", "f=", "@f", "{2}", "''", "'one'", "def", "@f"},
+			Want: []string{"1", "2"},
+		},
+		{
+			Args:    []string{"{1}", "''", "'s.size'", "def"},
+			Want:    []string{"{1}", "''", "'s.size'"},
+			WantErr: ErrIllegalName,
+		},
+		{
+			Args:    []string{"{1}", "''", "'1x'", "def"},
+			Want:    []string{"{1}", "''", "'1x'"},
+			WantErr: ErrIllegalName,
+		},
+		{
+			Args:    []string{"{1}", "2", "'one'", "def"},
+			Want:    []string{"{1}", "2", "'one'"},
+			WantErr: ErrExpectedAString,
+		},
+		{
+			Args:    []string{"''", "'one'", "def"},
+			Want:    []string{"''", "'one'"},
+			WantErr: ErrNotEnoughStackFrames,
+		},
+		{
+			Args:    []string{"{1}", "''", "'one'", "def", "'one'", "undef", "one"},
+			WantErr: ErrSyntax,
+		},
+		{
+			Args:    []string{"'s.size'", "undef"},
+			Want:    []string{"'s.size'"},
+			WantErr: ErrNotFound,
+		},
+	}
+	UnitTestExecAll(t, data, nil)
+}
+
+func TestDefHelpAndNames(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	var out strings.Builder
+	r.Print = func(msg string) { out.WriteString(msg) }
+	if err := r.ExecSlice([]string{"{1}", "'Pushes one'", "'one'", "def", "one?"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Pushes one") {
+		t.Errorf("help=%q", out.String())
+	}
+	found := false
+	for _, name := range r.AllFunctionNames() {
+		if name == "one" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("AllFunctionNames() did not contain one")
+	}
+	if err := r.ExecSlice([]string{"'one'", "undef"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.help[CatUser]; ok {
+		t.Error("expected empty user category to be removed")
+	}
+}
+
+func TestDefSnapshot(t *testing.T) {
+	var r RPN
+	r.Init(256)
+	if err := r.ExecSlice([]string{"{(x) $x $x}", "'Duplicates x'", "'twice'", "def", "{1}", "''", "'one'", "def"}); err != nil {
+		t.Fatal(err)
+	}
+	got := string(r.DefSnapshot(nil))
+	want := "{1} '' 'one' def\n{(x) $x $x} 'Duplicates x' 'twice' def\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	var r2 RPN
+	r2.Init(256)
+	if err := r2.ExecMacro(got); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.ExecSlice([]string{"5", "twice", "one"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r2.Frames) != 3 {
+		t.Errorf("got %d frames, want 3", len(r2.Frames))
+	}
+}
@@ -2,9 +2,9 @@ package rpn
 
 import (
 	"mattwach/rpngo/elog"
-	"mattwach/rpngo/parse"
 	"sort"
 	"strconv"
+	"strings"
 )
 
 const listVariablesHelp = "Prints all variable names"
@@ -31,9 +31,23 @@ func (r *RPN) SetVariable(name string) error {
 		r.PushFrame(f)
 		return err
 	}
+	name = r.scopedName(name)
 	vlist := r.variables[name]
-	if len(vlist) > 0 {
+	base := r.localBase(name)
+	from := base
+	if len(vlist) > base {
+		from = len(vlist) - 1
+	}
+	if err := r.checkWritableVariable(name, from); err != nil {
+		r.PushFrame(f)
+		return err
+	}
+	r.recordUndoVar(name)
+	if len(vlist) > base {
 		vlist[len(vlist)-1] = f
+	} else if len(vlist) > 0 {
+		// a cleared parameter, the caller's value is below it
+		r.variables[name] = append(vlist, f)
 	} else {
 		elog.Heap("alloc: rpn/vars.go:36: r.variables[name] = []Frame{f}")
 		r.variables[name] = []Frame{f} // object allocated on the heap: escapes at line 23
@@ -41,10 +55,17 @@ func (r *RPN) SetVariable(name string) error {
 	return nil
 }
 
+// ConstPrefix starts the names of the read-only constants.  Variables can
+// not use it, so a stray const.c= can not hide the constant.
+const ConstPrefix = "const."
+
 func checkVariableName(name string) error {
 	if len(name) == 0 {
 		return ErrIllegalName
 	}
+	if strings.HasPrefix(name, ConstPrefix) {
+		return ErrIllegalName
+	}
 	if !isAlpha(rune(name[0])) {
 		return ErrIllegalName
 	}
@@ -76,11 +97,15 @@ func (r *RPN) ClearVariable(name string) error {
 	if isNum(rune(name[0])) {
 		return r.clearStackVariable(name)
 	}
-	_, ok := r.variables[name]
-	if !ok {
+	name = r.scopedName(name)
+	base := r.localBase(name)
+	if len(r.variables[name]) <= base {
 		return ErrNotFound
 	}
-	delete(r.variables, name)
+	if err := r.checkWritableVariable(name, base); err != nil {
+		return err
+	}
+	r.dropValues(name, base)
 	return nil
 }
 
@@ -92,7 +117,7 @@ func (r *RPN) GetVariable(name string) (Frame, error) {
 	if isNum(rune(name[0])) {
 		return r.getStackVariable(name)
 	}
-	vlist := r.variables[name]
+	vlist := r.variables[r.variableKey(name)]
 	if len(vlist) == 0 {
 		return Frame{}, ErrNotFound
 	}
@@ -135,15 +160,9 @@ func (r *RPN) moveVariableToHead(name string) error {
 		return r.PushFrame(f)
 	}
 	// It's a named variable, e.g. x>
-	vlist := r.variables[name]
-	if len(vlist) == 0 {
-		return ErrNotFound
-	}
-	f := vlist[len(vlist)-1]
-	if len(vlist) == 1 {
-		delete(r.variables, name)
-	} else {
-		r.variables[name] = r.variables[name][:len(vlist)-1]
+	f, err := r.PopVariable(name)
+	if err != nil {
+		return err
 	}
 	return r.PushFrame(f)
 }
@@ -172,6 +191,11 @@ func (r *RPN) moveHeadToVariable(name string) error {
 	if err := checkVariableName(name); err != nil {
 		return err
 	}
+	name = r.scopedName(name)
+	if err := r.checkWritableVariable(name, len(r.variables[name])); err != nil {
+		return err
+	}
+	r.recordUndoVar(name)
 	f, err := r.PopFrame()
 	if err != nil {
 		return err
@@ -184,12 +208,21 @@ func (r *RPN) moveAllStackToVariable(name string) error {
 	if err := checkVariableName(name); err != nil {
 		return err
 	}
+	name = r.scopedName(name)
+	base := r.localBase(name)
+	if err := r.checkWritableVariable(name, base); err != nil {
+		return err
+	}
+	r.recordUndoVar(name)
 	if len(r.Frames) == 0 {
 		return ErrStackEmpty
 	}
-	elog.Heap("alloc: rpn/vars.go:187: r.variables[name] = make([]Frame, len(r.Frames))")
-	r.variables[name] = make([]Frame, len(r.Frames)) // object allocated on the heap: size is not constant
-	copy(r.variables[name], r.Frames)
+	vlist := r.variables[name]
+	elog.Heap("alloc: rpn/vars.go:187: vals := make([]Frame, base+len(r.Frames))")
+	vals := make([]Frame, base+len(r.Frames)) // object allocated on the heap: size is not constant
+	copy(vals, vlist[:base])
+	copy(vals[base:], r.Frames)
+	r.variables[name] = vals
 	r.Frames = r.Frames[:0]
 	return nil
 }
@@ -198,10 +231,12 @@ func (r *RPN) appendAllVariableToStack(name string) error {
 	if err := checkVariableName(name); err != nil {
 		return err
 	}
-	if len(r.variables[name]) == 0 {
+	key := r.variableKey(name)
+	vlist := r.variables[key][r.localBase(key):]
+	if len(vlist) == 0 {
 		return ErrNotFound
 	}
-	r.Frames = append(r.Frames, r.variables[name]...)
+	r.Frames = append(r.Frames, vlist...)
 	return nil
 }
 
@@ -209,7 +244,12 @@ func (r *RPN) appendAllStackToVariable(name string) error {
 	if err := checkVariableName(name); err != nil {
 		return err
 	}
-	if (len(r.Frames) == 0) && (len(r.variables[name]) == 0) {
+	name = r.scopedName(name)
+	if err := r.checkWritableVariable(name, len(r.variables[name])); err != nil {
+		return err
+	}
+	r.recordUndoVar(name)
+	if (len(r.Frames) == 0) && (len(r.variables[name]) <= r.localBase(name)) {
 		return ErrStackEmpty
 	}
 	r.variables[name] = append(r.variables[name], r.Frames...)
@@ -221,14 +261,114 @@ func (r *RPN) moveAllVariableToStack(name string) error {
 	if err := checkVariableName(name); err != nil {
 		return err
 	}
-	if len(r.variables[name]) == 0 {
+	name = r.scopedName(name)
+	base := r.localBase(name)
+	vlist := r.variables[name]
+	if len(vlist) <= base {
 		return ErrNotFound
 	}
-	r.Frames = append(r.Frames, r.variables[name]...)
-	delete(r.variables, name)
+	if err := r.checkWritableVariable(name, base); err != nil {
+		return err
+	}
+	r.Frames = append(r.Frames, vlist[base:]...)
+	r.dropValues(name, base)
+	return nil
+}
+
+// GetVariableStack returns all of the values of a variable, with the
+// current value last.  The returned slice must not be modified.
+func (r *RPN) GetVariableStack(name string) ([]Frame, error) {
+	if err := checkVariableName(name); err != nil {
+		return nil, err
+	}
+	vlist := r.variables[r.variableKey(name)]
+	if len(vlist) == 0 {
+		return nil, ErrNotFound
+	}
+	return vlist, nil
+}
+
+// PushVariable pushes f onto the named variable's stack, hiding the
+// previous value until PopVariable is called.
+func (r *RPN) PushVariable(name string, f Frame) error {
+	if err := checkVariableName(name); err != nil {
+		return err
+	}
+	name = r.scopedName(name)
+	if err := r.checkWritableVariable(name, len(r.variables[name])); err != nil {
+		return err
+	}
+	r.recordUndoVar(name)
+	r.variables[name] = append(r.variables[name], f)
 	return nil
 }
 
+// PopVariable removes the most recent value of a named variable, restoring
+// the previous one (if any).
+func (r *RPN) PopVariable(name string) (Frame, error) {
+	name = r.scopedName(name)
+	vlist := r.variables[name]
+	if len(vlist) <= r.localBase(name) {
+		return Frame{}, ErrNotFound
+	}
+	if err := r.checkWritableVariable(name, len(vlist)-1); err != nil {
+		return Frame{}, err
+	}
+	r.recordUndoVar(name)
+	f := vlist[len(vlist)-1]
+	if len(vlist) == 1 {
+		delete(r.variables, name)
+	} else {
+		r.variables[name] = vlist[:len(vlist)-1]
+	}
+	return f, nil
+}
+
+// PushLocal pushes f onto the named variable for a value that is removed
+// again with TruncateVariable, such as a loop variable.
+func (r *RPN) PushLocal(name string, f Frame) error {
+	if err := checkVariableName(name); err != nil {
+		return err
+	}
+	return r.pushLocal(r.scopedName(name), f)
+}
+
+// VariableDepth returns the number of values the named variable holds.
+// Pass it to TruncateVariable to remove the values pushed after it.
+func (r *RPN) VariableDepth(name string) int {
+	return len(r.variables[r.scopedName(name)])
+}
+
+// TruncateVariable removes the values that were pushed to the named
+// variable after it held depth values.  Unlike PopVariable, a macro that
+// pops more values than it pushed can not make it remove values that
+// belong to the caller.
+func (r *RPN) TruncateVariable(name string, depth int) {
+	r.truncateVariable(r.scopedName(name), depth)
+}
+
+func (r *RPN) truncateVariable(key string, depth int) {
+	if base, ok := r.sandbox.owned[key]; ok && (depth <= base) {
+		delete(r.sandbox.owned, key)
+	}
+	r.dropValues(key, depth)
+}
+
+// dropValues removes the values of variable key above depth without
+// changing who owns it, e.g. when a macro clears its own parameter.
+func (r *RPN) dropValues(key string, depth int) {
+	vlist := r.variables[key]
+	if len(vlist) <= depth {
+		return
+	}
+	r.recordUndoVar(key)
+	if depth == 0 {
+		delete(r.variables, key)
+	} else {
+		r.variables[key] = vlist[:depth]
+	}
+}
+
 // gets a variable as a string
 func (r *RPN) GetStringVariable(name string) (string, error) {
 	v, err := r.GetVariable(name)
@@ -283,11 +423,21 @@ func (r *RPN) execVariableAsMacro(name string) error {
 	if !f.IsString() {
 		return ErrExpectedAString
 	}
-	// this call can be recursive so we need to allocate here
-	if err := parse.Fields(f.UnsafeString(), r.Exec); err != nil {
-		return err
-	}
-	return nil
+	m, err := r.CompileMacro(f.UnsafeString())
+	if err != nil {
+		return r.newExecError(err)
+	}
+	key := r.variableKey(name)
+	// macros stored in a module run in that module's namespace
+	saved := r.setNamespace(r.moduleOf(key))
+	defer r.setNamespace(saved)
+	if r.sandboxOwns(key) && !r.Sandboxed() {
+		// the macro was stored by sandboxed code
+		return r.RunSandboxed(func() error {
+			return r.execCompiled(m, name)
+		})
+	}
+	return r.execCompiled(m, name)
 }
 
 const varSnapshotHelp = "Creates a string that defines current variables"
@@ -326,6 +476,9 @@ func (r *RPN) snapshotOneVar(buff []byte, name string, vals []Frame) []byte {
 const varClearHelp = "Clears all variables that do not start with a ."
 
 func varClear(r *RPN) error {
+	if r.Sandboxed() && !r.sandbox.global {
+		return ErrSandboxed
+	}
 	var delnames []string
 	for name := range r.variables {
 		if (len(name) > 0) && (name[0] != '.') {
@@ -333,6 +486,7 @@ func varClear(r *RPN) error {
 		}
 	}
 	for _, name := range delnames {
+		r.recordUndoVar(name)
 		delete(r.variables, name)
 	}
 	return nil
@@ -341,6 +495,12 @@ func varClear(r *RPN) error {
 const varClearAllHelp = "Clears all variables (including . ones)"
 
 func varClearAll(r *RPN) error {
+	if r.Sandboxed() {
+		return ErrSandboxed
+	}
+	for name := range r.variables {
+		r.recordUndoVar(name)
+	}
 	r.variables = make(map[string][]Frame)
 	return nil
 }
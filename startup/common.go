@@ -10,9 +10,10 @@ import (
 )
 
 const commonStartup = `
-# set some useful vars
-3.141592653589793 pi=
-2.718281828459045 e=
+# kept for scripts that use $pi and $e, which are deprecated in favor of
+# the read-only const.pi and const.e
+const.pi pi=
+const.e e=
 
 # some useful equations
 # (-b +/- sqrt(b*b - 4*a*c)) / (2 * a)
@@ -41,7 +42,7 @@ rad
 const configName = ".rpngo"
 
 // Startup tries to load .rpngo and tries to create a default
-// file if one can not be loaded.
+// file if one can not be loaded, then runs the .onstartup hook.
 func Startup(r *rpn.RPN, fs fileops.FileOpsDriver) error {
 	configPath, err := genConfigPath()
 	if err != nil {
@@ -52,7 +53,7 @@ func Startup(r *rpn.RPN, fs fileops.FileOpsDriver) error {
 	if err != nil {
 		return fmt.Errorf("while parsing %s: %w", configPath, err)
 	}
-	return nil
+	return r.RunHook(rpn.HookStartup)
 }
 
 func genConfigPath() (string, error) {
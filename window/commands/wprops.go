@@ -100,6 +100,7 @@ func (wc *WindowCommands) snapshot(r *rpn.RPN) error {
 	buff := make([]byte, 0, 256)
 	buff = append(buff, []byte("d\nv.clearall\nw.reset\n")...)
 	buff, _ = wc.root.Snapshot(buff, "root")
+	buff = r.DefSnapshot(buff)
 	buff = r.VarSnapshot(buff)
 	buff = r.StackSnapshot(buff)
 	return r.PushFrame(rpn.StringFrame(string(buff), rpn.STRING_BRACE_FRAME))
@@ -0,0 +1,58 @@
+package input
+
+import (
+	"mattwach/rpngo/key"
+	"mattwach/rpngo/rpn"
+	"mattwach/rpngo/window"
+	"strconv"
+)
+
+// number of stack values to show when the debugger pauses
+const debugStackFrames = 4
+
+// debugPrompt shows where the debugger is paused and waits for a single
+// key press.
+func (iw *InputWindow) debugPrompt(r *rpn.RPN, state rpn.DebugState) (rpn.DebugAction, error) {
+	iw.txtb.TextColor(window.Green)
+	iw.txtb.Print("["+strconv.Itoa(state.Depth)+"] ", false)
+	iw.txtb.TextColor(window.Yellow)
+	iw.txtb.Print(state.Context, false)
+	iw.txtb.Write('\n', false)
+	iw.printStack(r)
+	iw.txtb.TextColor(window.White)
+	iw.txtb.Print("(s)tep (n)ext (c)ontinue (q)uit", true)
+	iw.txtb.Cursor(true)
+	defer func() {
+		iw.txtb.Cursor(false)
+		iw.txtb.Write('\n', true)
+	}()
+	for {
+		c, err := getKey(iw.input)
+		if err != nil {
+			return rpn.DebugAbort, err
+		}
+		switch c {
+		case 's', 'S', ' ', '\n':
+			return rpn.DebugStep, nil
+		case 'n', 'N':
+			return rpn.DebugStepOver, nil
+		case 'c', 'C':
+			return rpn.DebugContinue, nil
+		case 'q', 'Q', key.KEY_BREAK, key.KEY_EOF:
+			return rpn.DebugAbort, nil
+		}
+	}
+}
+
+func (iw *InputWindow) printStack(r *rpn.RPN) {
+	count := len(r.Frames)
+	if count > debugStackFrames {
+		count = debugStackFrames
+	}
+	iw.txtb.TextColor(window.Cyan)
+	for i := count - 1; i >= 0; i-- {
+		f := r.Frames[len(r.Frames)-1-i]
+		iw.txtb.Print("  "+strconv.Itoa(i)+": "+f.String(true), false)
+		iw.txtb.Write('\n', false)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"mattwach/rpngo/key"
 	"mattwach/rpngo/rpn"
 	"mattwach/rpngo/window"
+	"strconv"
+	"strings"
 )
 
 // Provides a UI for editing a multiline string
@@ -31,6 +33,8 @@ type editor struct {
 
 	replaceMode bool
 	changed     bool
+	// if true, the buffer is checked with lint when it is saved
+	lint bool
 }
 
 type HighlightState uint8
@@ -44,6 +48,22 @@ const (
 	HIGHLIGHT_COMMENT
 )
 
+// lintMessage returns the first problem found by lint, if any
+func (ed *editor) lintMessage(r *rpn.RPN) string {
+	if !ed.lint {
+		return ""
+	}
+	res := r.Lint(string(ed.buff))
+	switch len(res.Issues) {
+	case 0:
+		return ""
+	case 1:
+		return "\n" + res.Issues[0].String()
+	default:
+		return "\n" + res.Issues[0].String() + "\n(" + strconv.Itoa(len(res.Issues)-1) + " more, use lint)"
+	}
+}
+
 const editHelp = "Invokes an editor on the head value of the stack. "
 
 func (iw *InputWindow) edit(r *rpn.RPN) error {
@@ -56,6 +76,7 @@ func (iw *InputWindow) edit(r *rpn.RPN) error {
 			return err
 		}
 		ed.buff = []byte(f.String(false))
+		ed.lint = f.Type() == rpn.STRING_BRACE_FRAME
 	}
 
 	save := func(buff []byte) error {
@@ -85,9 +106,14 @@ func (iw *InputWindow) editFile(r *rpn.RPN) error {
 
 	var ed editor
 	ed.buff, _ = iw.gl.fs.ReadFile(f.UnsafeString())
+	ed.lint = strings.HasSuffix(f.UnsafeString(), ".rpn")
 
 	save := func(buff []byte) error {
-		return iw.gl.fs.WriteFile(f.UnsafeString(), buff)
+		path, err := r.WritablePath(f.UnsafeString())
+		if err != nil {
+			return err
+		}
+		return iw.gl.fs.WriteFile(path, buff)
 	}
 
 	return ed.edit(r, iw, save)
@@ -118,7 +144,7 @@ func (ed *editor) edit(r *rpn.RPN, iw *InputWindow, save func([]byte) error) err
 			}
 		}
 		ed.renderDisplay()
-		c, err := iw.input.GetChar()
+		c, err := getKey(iw.input)
 		if err != nil {
 			return err
 		}
@@ -142,7 +168,7 @@ func (ed *editor) edit(r *rpn.RPN, iw *InputWindow, save func([]byte) error) err
 				err = nil
 			} else {
 				ed.changed = false
-				ed.message = "Saved"
+				ed.message = "Saved" + ed.lintMessage(r)
 			}
 		case key.KEY_UP:
 			ed.keyUpPressed()
@@ -126,7 +126,8 @@ func (gl *getLine) writeAllHistory() error {
 	return err
 }
 
-func (gl *getLine) get(r *rpn.RPN) (string, error) {
+// get reads a line.  The .ontimer hook runs while waiting if hooks is true.
+func (gl *getLine) get(r *rpn.RPN, hooks bool) (string, error) {
 	gl.txtb.Cursor(true)
 	defer gl.txtb.Cursor(false)
 	gl.line = gl.line[:0]
@@ -180,6 +181,10 @@ func (gl *getLine) get(r *rpn.RPN) (string, error) {
 			idx = 0
 		case '\t':
 			idx = gl.tabComplete(r, idx)
+		case key.KEY_TIMER:
+			if hooks {
+				gl.runTimer(r, idx)
+			}
 		case 27: // ESCAPE key
 			gl.enterScrollingMode(0)
 		case key.KEY_PAGEUP:
@@ -229,6 +234,21 @@ func (gl *getLine) get(r *rpn.RPN) (string, error) {
 	}
 }
 
+// runTimer runs the .ontimer hook.  If it fails, the error is shown and
+// the line being edited is printed again below it.
+func (gl *getLine) runTimer(r *rpn.RPN, idx int) {
+	err := r.RunHook(rpn.HookTimer)
+	if err == nil {
+		return
+	}
+	gl.txtb.Shift(len(gl.line) - idx)
+	gl.txtb.Write('\n', true)
+	showError(gl.txtb, r, err)
+	gl.txtb.Print("> ", true)
+	gl.txtb.PrintBytes(gl.line, true)
+	gl.txtb.Shift(idx - len(gl.line))
+}
+
 func (gl *getLine) execMacro(r *rpn.RPN, idx int, name string) (string, error) {
 	gl.txtb.Shift(-idx)
 	for idx > 0 {
@@ -320,7 +340,7 @@ func (gl *getLine) enterScrollingMode(delta int) {
 	}
 	var exit bool
 	for {
-		c, err := gl.input.GetChar()
+		c, err := getKey(gl.input)
 		if err != nil {
 			return
 		}
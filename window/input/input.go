@@ -26,6 +26,8 @@ type InputWindow struct {
 	firstInput bool
 	showFrames int
 	autofn     []string
+	// used by properties that need the calculator
+	r *rpn.RPN
 }
 
 func (iw *InputWindow) Init(input Input, txtw window.TextWindow, r *rpn.RPN, fs fileops.FileOpsDriver, scrollbytes int) {
@@ -35,8 +37,10 @@ func (iw *InputWindow) Init(input Input, txtw window.TextWindow, r *rpn.RPN, fs
 	iw.gl = initGetLine(input, &iw.txtb, fs)
 	iw.firstInput = true
 	iw.showFrames = 1
+	iw.r = r
 	r.Print = iw.Print
 	r.Input = iw.Input
+	r.DebugPrompt = iw.debugPrompt
 	r.Register("edit", iw.edit, rpn.CatProg, editHelp)
 	r.Register("editf", iw.editFile, rpn.CatProg, editFileHelp)
 	r.Register("histl", iw.gl.histLoad, rpn.CatStatus, histLoadHelp)
@@ -48,7 +52,7 @@ func (iw *InputWindow) Print(msg string) {
 }
 
 func (iw *InputWindow) Input(r *rpn.RPN) (string, error) {
-	return iw.gl.get(r)
+	return iw.gl.get(r, false)
 }
 
 func (iw *InputWindow) Update(r *rpn.RPN, unusedForce bool) error {
@@ -62,16 +66,14 @@ func (iw *InputWindow) Update(r *rpn.RPN, unusedForce bool) error {
 	r.TextWidth = iw.txtb.Txtw.TextWidth()
 	if len(iw.autofn) != 0 {
 		if err := r.ExecSlice(iw.autofn); err != nil {
-			iw.txtb.TextColor(window.Red)
-			r.Println(err.Error())
-			iw.txtb.TextColor(window.White)
+			showError(&iw.txtb, r, err)
 		}
 	}
 	iw.txtb.Print("> ", true)
-	line, err := iw.gl.get(r)
+	line, err := iw.gl.get(r, true)
 	iw.txtb.TextColor(window.Yellow)
 	if err != nil {
-		iw.txtb.PrintErr(err, true)
+		showError(&iw.txtb, r, err)
 		return nil
 	}
 	line = strings.TrimSpace(line)
@@ -83,16 +85,35 @@ func (iw *InputWindow) Update(r *rpn.RPN, unusedForce bool) error {
 	}
 	action, err := parseLine(r, line)
 	if err != nil {
-		iw.txtb.PrintErr(err, true)
-		return nil
-	}
-	if action {
+		showError(&iw.txtb, r, err)
+	} else if action {
 		iw.printFrames(r)
 	}
+	if err := r.RunHook(rpn.HookUpdate); err != nil {
+		showError(&iw.txtb, r, err)
+	}
 	iw.txtb.Update()
 	return nil
 }
 
+// showError prints err and runs the .onerror hook
+func showError(txtb *window.TextBuffer, r *rpn.RPN, err error) {
+	txtb.PrintErr(err, true)
+	if err := r.RunErrorHook(err); err != nil {
+		txtb.PrintErr(err, true)
+	}
+}
+
+// getKey returns the next key press, ignoring timer ticks
+func getKey(in Input) (key.Key, error) {
+	for {
+		c, err := in.GetChar()
+		if (err != nil) || (c != key.KEY_TIMER) {
+			return c, err
+		}
+	}
+}
+
 func (iw *InputWindow) printFrames(r *rpn.RPN) {
 	count := len(r.Frames)
 	if iw.showFrames < count {
@@ -144,6 +165,9 @@ func (iw *InputWindow) Type() string {
 }
 
 func parseLine(r *rpn.RPN, line string) (bool, error) {
+	// each line is a single step for undo, even if it fails partway
+	r.BeginUndoStep()
+	defer r.EndUndoStep()
 	if err := parse.Fields(line, r.Exec); err != nil {
 		return false, err
 	}
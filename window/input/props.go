@@ -7,6 +7,7 @@ import (
 )
 
 const MAX_SHOW_FRAMES = 1000
+const MAX_UNDO_MEMORY = 1 << 20
 
 func (iw *InputWindow) SetProp(name string, val rpn.Frame) error {
 	switch name {
@@ -29,6 +30,10 @@ func (iw *InputWindow) SetProp(name string, val rpn.Frame) error {
 		iw.gl.autoHistory = enabled
 		return nil
 	case "histpath":
+		if iw.r.Sandboxed() {
+			// autohist would append to any file
+			return rpn.ErrSandboxed
+		}
 		if !val.IsString() {
 			return rpn.ErrExpectedAString
 		}
@@ -41,6 +46,12 @@ func (iw *InputWindow) SetProp(name string, val rpn.Frame) error {
 		}
 		iw.showFrames = int(v)
 		return nil
+	case "undomem":
+		v, err := val.BoundedInt(0, MAX_UNDO_MEMORY)
+		if err != nil {
+			return err
+		}
+		return iw.r.SetUndoMemory(int(v))
 	}
 	return rpn.ErrNotSupported
 }
@@ -55,11 +66,13 @@ func (iw *InputWindow) GetProp(name string) (rpn.Frame, error) {
 		return rpn.StringFrame(iw.gl.histpath, rpn.STRING_SINGLEQ_FRAME), nil
 	case "showframes":
 		return rpn.IntFrame(int64(iw.showFrames), rpn.INTEGER_FRAME), nil
+	case "undomem":
+		return rpn.IntFrame(int64(iw.r.UndoMemory()), rpn.INTEGER_FRAME), nil
 	}
 	return rpn.Frame{}, rpn.ErrNotSupported
 }
 
-var inputProps = []string{"autofn", "autohist", "histpath", "showframes"}
+var inputProps = []string{"autofn", "autohist", "histpath", "showframes", "undomem"}
 
 func (iw *InputWindow) ListProps() []string {
 	return inputProps
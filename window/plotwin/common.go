@@ -52,6 +52,8 @@ type Plot struct {
 	fn           []string
 	coloridx     uint8
 	isParametric bool
+	// compiled version of fn, created when the plot is first drawn
+	macro *rpn.Macro
 }
 
 type plotWindowCommon struct {
@@ -108,6 +110,7 @@ func (pw *plotWindowCommon) setPlotFn(fnstr string, idx int) error {
 	elog.Heap("alloc: window/plotwin/common.go:106: p := &pw.plots[idx]")
 	p := &pw.plots[idx] // object allocated on the heap: escapes at line 112
 	p.fn = p.fn[:0]
+	p.macro = nil
 	addField := func(t string) error {
 		p.fn = append(p.fn, t)
 		return nil
@@ -124,8 +127,8 @@ func (pw *plotWindowCommon) setAxisMinMax(r *rpn.RPN) {
 	// first determine the ranges
 	if pw.autox || pw.autoy {
 		pw.stats.reset()
-		for _, plot := range pw.plots {
-			if err := pw.addPoints(r, plot, pw.steps, pw.stats.update); err != nil {
+		for i := range pw.plots {
+			if err := pw.addPoints(r, &pw.plots[i], pw.steps, pw.stats.update); err != nil {
 				// this plot has some type of error, but there is nothing to be done
 				// here outside of not contributing any more points from this point
 				// to the stats
@@ -142,24 +145,29 @@ func (pw *plotWindowCommon) setAxisMinMax(r *rpn.RPN) {
 
 func (pw *plotWindowCommon) createPoints(r *rpn.RPN, fn func(x, y float64, coloridx uint8) error) error {
 	var finalErr error
-	for i, plot := range pw.plots {
+	for i := range pw.plots {
+		plot := &pw.plots[i]
 		if err := pw.addPoints(r, plot, pw.steps, fn); err != nil {
 			r.Print("error plotting {")
 			r.Print(strings.Join(plot.fn, " "))
 			r.Print("}: ")
 			r.Print(err.Error())
 			r.Println(" (removing plot)")
-			pw.plots[i].fn = pw.plots[i].fn[:0]
+			plot.fn = plot.fn[:0]
+			plot.macro = nil
 			finalErr = err
 		}
 	}
 	return finalErr
 }
 
-func (pw *plotWindowCommon) addPoints(r *rpn.RPN, plot Plot, steps uint32, fn func(x, y float64, coloridx uint8) error) error {
+func (pw *plotWindowCommon) addPoints(r *rpn.RPN, plot *Plot, steps uint32, fn func(x, y float64, coloridx uint8) error) error {
 	if len(plot.fn) == 0 {
 		return nil
 	}
+	if plot.macro == nil {
+		plot.macro = r.CompileFields(plot.fn)
+	}
 	startlen := r.StackLen()
 	step := (pw.maxv - pw.minv) / float64(steps)
 	var x float64
@@ -173,7 +181,7 @@ func (pw *plotWindowCommon) addPoints(r *rpn.RPN, plot Plot, steps uint32, fn fu
 		if err := r.PushFrame(rpn.RealFrame(v)); err != nil {
 			return err
 		}
-		if err := r.ExecSlice(plot.fn); err != nil {
+		if err := r.ExecCompiled(plot.macro); err != nil {
 			if errors.Is(err, rpn.ErrDivideByZero) {
 				// just skip this point
 				continue